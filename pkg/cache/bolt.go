@@ -0,0 +1,298 @@
+// Package cache provides URL de-duplication stores shared across pipeline stages
+/*
+ * File: bolt.go
+ * Project: cache
+ * File Created: Saturday, 17th October 2026 5:35:40 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:03:16 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cache
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// entriesBucket is the name of the bucket holding the cache entries
+var entriesBucket = []byte("entries")
+
+// lockTimeout is the maximum time to wait for another process to release the database file
+const lockTimeout = 30 * time.Second
+
+// boltCache is a Cache persisted to an embedded key-value file.
+//
+// The database file is only opened for the duration of each transaction so that
+// several processes in a pipeline (e.g. fetch | download) can share a single cache file.
+type boltCache struct {
+	path string
+	ttl  time.Duration
+	sync.Mutex
+}
+
+// Open is a function for opening, or creating, a persistent Cache at path.
+// Entries older than ttl are treated as absent; a ttl of 0 disables expiry.
+func Open(path string, ttl time.Duration) (Cache, error) {
+	c := &boltCache{path: path, ttl: ttl}
+
+	// Ensure the database and bucket exist and are readable
+	err := c.update(func(b *bolt.Bucket) error { return nil })
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open cache [%s]", path)
+	}
+
+	return c, nil
+}
+
+// Has reports whether url has been recorded at stage or a later stage and has not expired
+func (c *boltCache) Has(stage Stage, url string) (bool, error) {
+	var found bool
+
+	err := c.view(func(b *bolt.Bucket) error {
+		e, err := getEntry(b, NormalizeURL(url))
+		if err != nil || e == nil {
+			return err
+		}
+
+		found = e.has(stage) && !e.expired(c.ttl, time.Now())
+		return nil
+	})
+
+	return found, err
+}
+
+// Add records urls at stage
+func (c *boltCache) Add(stage Stage, urls ...string) error {
+	_, err := c.record(stage, urls, false)
+	return err
+}
+
+// Claim records urls at stage and returns those not already recorded at stage or a later stage
+func (c *boltCache) Claim(stage Stage, urls ...string) ([]string, error) {
+	return c.record(stage, urls, true)
+}
+
+// Release reverts the Claim of urls at stage
+func (c *boltCache) Release(stage Stage, urls ...string) error {
+	return c.update(func(b *bolt.Bucket) error {
+		for _, u := range urls {
+			normalized := NormalizeURL(u)
+
+			e, err := getEntry(b, normalized)
+			if err != nil {
+				return err
+			}
+			if e == nil {
+				continue
+			}
+
+			if e.unmark(stage) {
+				if err := deleteEntry(b, normalized); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := putEntry(b, normalized, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *boltCache) record(stage Stage, urls []string, claim bool) ([]string, error) {
+	claimed := []string{}
+
+	err := c.update(func(b *bolt.Bucket) error {
+		now := time.Now()
+		claimed = claimed[:0]
+
+		for _, u := range urls {
+			normalized := NormalizeURL(u)
+
+			e, err := getEntry(b, normalized)
+			if err != nil {
+				return err
+			}
+
+			if e == nil || e.expired(c.ttl, now) {
+				e = newEntry(normalized)
+			} else if claim && e.has(stage) {
+				continue
+			}
+
+			e.mark(stage, now)
+			if err := putEntry(b, normalized, e); err != nil {
+				return err
+			}
+
+			claimed = append(claimed, u)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// Stats returns the cache statistics
+func (c *boltCache) Stats() (*Stats, error) {
+	stats := &Stats{Path: c.path}
+
+	err := c.view(func(b *bolt.Bucket) error {
+		now := time.Now()
+		return b.ForEach(func(k, v []byte) error {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			stats.count(&e, c.ttl, now)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err := os.Stat(c.path); err == nil {
+		stats.Size = fi.Size()
+	}
+
+	return stats, nil
+}
+
+// Clear removes all or only the expired entries from the cache
+func (c *boltCache) Clear(expiredOnly bool) (int, error) {
+	removed := 0
+
+	err := c.update(func(b *bolt.Bucket) error {
+		now := time.Now()
+		removed = 0
+
+		// Collect keys first; deleting while iterating a cursor skips entries
+		var keys [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if expiredOnly {
+				var e Entry
+				if err := json.Unmarshal(v, &e); err != nil {
+					return err
+				}
+				if !e.expired(c.ttl, now) {
+					return nil
+				}
+			}
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// Export writes all cache entries to w as JSON Lines, ordered by key
+func (c *boltCache) Export(w io.Writer) error {
+	return c.view(func(b *bolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			_, err := w.Write(append(v, '\n'))
+			return err
+		})
+	})
+}
+
+// Close is a no-op as the database file is only held open during transactions
+func (c *boltCache) Close() error {
+	return nil
+}
+
+// update is a helper function for executing fn in a read-write transaction on the entries bucket
+func (c *boltCache) update(fn func(b *bolt.Bucket) error) error {
+	c.Lock()
+	defer c.Unlock()
+
+	db, err := bolt.Open(c.path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(entriesBucket)
+		if err != nil {
+			return err
+		}
+		return fn(b)
+	})
+}
+
+// view is a helper function for executing fn in a read-only transaction on the entries bucket
+func (c *boltCache) view(fn func(b *bolt.Bucket) error) error {
+	c.Lock()
+	defer c.Unlock()
+
+	db, err := bolt.Open(c.path, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(entriesBucket)
+		if b == nil {
+			return nil
+		}
+		return fn(b)
+	})
+}
+
+// getEntry is a helper function for retrieving the entry of a normalized URL from the bucket. Returns nil if not found.
+func getEntry(b *bolt.Bucket, normalized string) (*Entry, error) {
+	v := b.Get([]byte(entryKey(normalized)))
+	if v == nil {
+		return nil, nil
+	}
+
+	var e Entry
+	if err := json.Unmarshal(v, &e); err != nil {
+		return nil, errors.Wrapf(err, "corrupt cache entry [%s]", newEntry(normalized).URL)
+	}
+
+	return &e, nil
+}
+
+// putEntry is a helper function for storing the entry of a normalized URL in the bucket
+func putEntry(b *bolt.Bucket, normalized string, e *Entry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(entryKey(normalized)), v)
+}
+
+// deleteEntry is a helper function for removing the entry of a normalized URL from the bucket
+func deleteEntry(b *bolt.Bucket, normalized string) error {
+	return b.Delete([]byte(entryKey(normalized)))
+}
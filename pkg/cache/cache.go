@@ -0,0 +1,180 @@
+// Package cache provides URL de-duplication stores shared across pipeline stages
+/*
+ * File: cache.go
+ * Project: cache
+ * File Created: Saturday, 17th October 2026 5:35:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:51:24 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxEntryURL is the maximum length of the URL recorded in an Entry; longer URLs e.g. data URIs are truncated
+const maxEntryURL = 2048
+
+// Stage is a type for representing the pipeline stage a URL has been recorded at.
+// Stages are ordered; a URL recorded at a later stage is considered seen at all earlier stages.
+type Stage int
+
+const (
+	// Fetched indicates the URL was emitted by a fetch
+	Fetched Stage = iota + 1
+	// Downloaded indicates the URL was successfully downloaded
+	Downloaded
+)
+
+// Cache is an interface for URL de-duplication stores
+type Cache interface {
+	// Has reports whether url has been recorded at stage or a later stage and has not expired
+	Has(stage Stage, url string) (bool, error)
+	// Add records urls at stage
+	Add(stage Stage, urls ...string) error
+	// Claim records urls at stage and returns those that had not already been recorded
+	// at stage or a later stage. The check and record are atomic.
+	Claim(stage Stage, urls ...string) ([]string, error)
+	// Release reverts the Claim of urls at stage e.g. for claimed URLs which were never emitted
+	Release(stage Stage, urls ...string) error
+	// Stats returns the cache statistics
+	Stats() (*Stats, error)
+	// Clear removes all entries from the cache, or only the expired entries if expiredOnly is set
+	Clear(expiredOnly bool) (int, error)
+	// Export writes all cache entries to w as JSON Lines
+	Export(w io.Writer) error
+	// Close releases any resources held by the cache
+	Close() error
+}
+
+// Entry is a struct for representing a single cache entry.
+// Timestamps are Unix times of when the URL was last recorded at each stage.
+// The URL is informational and truncated to maxEntryURL; entries are keyed by the hash of the URL.
+type Entry struct {
+	URL          string `json:"url"`
+	FetchedAt    int64  `json:"fetched_at,omitempty"`
+	DownloadedAt int64  `json:"downloaded_at,omitempty"`
+}
+
+// Stats is a struct for representing a cache's statistics
+type Stats struct {
+	Path       string `json:"path,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	Entries    int    `json:"entries"`
+	Fetched    int    `json:"fetched"`
+	Downloaded int    `json:"downloaded"`
+	Expired    int    `json:"expired"`
+}
+
+// newEntry is a helper function for creating the entry of a normalized URL
+func newEntry(normalized string) *Entry {
+	if len(normalized) > maxEntryURL {
+		normalized = normalized[:maxEntryURL] + "..."
+	}
+	return &Entry{URL: normalized}
+}
+
+// entryKey is a helper function for deriving the key of a URL's entry: the hex SHA-256 of the normalized URL,
+// so keys have a fixed size however long the URL
+func entryKey(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// has reports whether the entry has been recorded at stage or a later stage
+func (e *Entry) has(stage Stage) bool {
+	switch stage {
+	case Fetched:
+		return e.FetchedAt != 0 || e.DownloadedAt != 0
+	case Downloaded:
+		return e.DownloadedAt != 0
+	}
+	return false
+}
+
+// mark records the entry at stage
+func (e *Entry) mark(stage Stage, now time.Time) {
+	switch stage {
+	case Fetched:
+		e.FetchedAt = now.Unix()
+	case Downloaded:
+		e.DownloadedAt = now.Unix()
+	}
+}
+
+// unmark removes the record of the entry at stage. Returns true if the entry is no longer recorded at any stage.
+func (e *Entry) unmark(stage Stage) bool {
+	switch stage {
+	case Fetched:
+		e.FetchedAt = 0
+	case Downloaded:
+		e.DownloadedAt = 0
+	}
+	return e.FetchedAt == 0 && e.DownloadedAt == 0
+}
+
+// expired reports whether the entry was last recorded more than ttl ago.
+// A ttl of 0 disables expiry.
+func (e *Entry) expired(ttl time.Duration, now time.Time) bool {
+	if ttl <= 0 {
+		return false
+	}
+
+	last := e.FetchedAt
+	if e.DownloadedAt > last {
+		last = e.DownloadedAt
+	}
+
+	return now.Sub(time.Unix(last, 0)) > ttl
+}
+
+// count is a helper function for accumulating an entry into a Stats object
+func (s *Stats) count(e *Entry, ttl time.Duration, now time.Time) {
+	s.Entries++
+	if e.expired(ttl, now) {
+		s.Expired++
+	}
+	if e.FetchedAt != 0 {
+		s.Fetched++
+	}
+	if e.DownloadedAt != 0 {
+		s.Downloaded++
+	}
+}
+
+// NormalizeURL is a function for normalizing a URL into a cache key.
+// Scheme-relative URLs are assumed to be http, the scheme and host are lower cased,
+// default ports and fragments are removed and query parameters are sorted.
+// URLs that cannot be parsed are returned trimmed but otherwise unmodified.
+func NormalizeURL(urlStr string) string {
+	urlStr = strings.TrimSpace(urlStr)
+	if strings.HasPrefix(urlStr, "//") {
+		urlStr = "http:" + urlStr
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return urlStr
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) ||
+		(u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+
+	u.Fragment = ""
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+
+	return u.String()
+}
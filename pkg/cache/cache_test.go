@@ -0,0 +1,133 @@
+/*
+ * File: cache_test.go
+ * Project: cache
+ * File Created: Saturday, 17th October 2026 5:36:51 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:03:16 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]struct {
+		url        string
+		normalized string
+	}{
+		"Scheme Relative": {"//live.staticflickr.com/1.jpg", "http://live.staticflickr.com/1.jpg"},
+		"Case":            {"HTTP://Example.COM/A.jpg", "http://example.com/A.jpg"},
+		"Default Port":    {"https://example.com:443/a.jpg", "https://example.com/a.jpg"},
+		"Custom Port":     {"https://example.com:8443/a.jpg", "https://example.com:8443/a.jpg"},
+		"Fragment":        {"http://example.com/a.jpg#top", "http://example.com/a.jpg"},
+		"Query Order":     {"http://example.com/a.jpg?w=1&h=2", "http://example.com/a.jpg?h=2&w=1"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+		assert.Equal(t, test.normalized, NormalizeURL(test.url))
+	}
+}
+
+func TestEntryExpired(t *testing.T) {
+	now := time.Now()
+	e := &Entry{FetchedAt: now.Add(-2 * time.Hour).Unix(), DownloadedAt: now.Add(-30 * time.Minute).Unix()}
+
+	assert.False(t, e.expired(0, now))
+	assert.False(t, e.expired(time.Hour, now))
+	assert.True(t, e.expired(10*time.Minute, now))
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	boltCache, err := Open(path.Join(dir, "cache.db"), 0)
+	assert.NoError(t, err)
+
+	tests := map[string]Cache{
+		"Memory": NewMemory(0),
+		"Bolt":   boltCache,
+	}
+
+	for name, c := range tests {
+		t.Logf("Running test %s", name)
+
+		// Duplicates are only claimed once, including differently formatted duplicates
+		claimed, err := c.Claim(Fetched, "http://a.com/1.jpg", "http://b.com/2.jpg", "HTTP://A.com/1.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"http://a.com/1.jpg", "http://b.com/2.jpg"}, claimed)
+
+		claimed, err = c.Claim(Fetched, "http://a.com/1.jpg", "http://c.com/3.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"http://c.com/3.jpg"}, claimed)
+
+		// Fetched URLs are not considered downloaded
+		ok, err := c.Has(Downloaded, "http://a.com/1.jpg")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		// Downloaded URLs are considered fetched
+		assert.NoError(t, c.Add(Downloaded, "http://d.com/4.jpg"))
+		ok, err = c.Has(Fetched, "http://d.com/4.jpg")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		stats, err := c.Stats()
+		assert.NoError(t, err)
+		assert.Equal(t, 4, stats.Entries)
+		assert.Equal(t, 3, stats.Fetched)
+		assert.Equal(t, 1, stats.Downloaded)
+
+		var buf bytes.Buffer
+		assert.NoError(t, c.Export(&buf))
+		assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 4)
+
+		// Released claims can be claimed again; releasing a download keeps the fetch
+		assert.NoError(t, c.Release(Fetched, "http://c.com/3.jpg"))
+		claimed, err = c.Claim(Fetched, "http://c.com/3.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"http://c.com/3.jpg"}, claimed)
+
+		assert.NoError(t, c.Add(Downloaded, "http://c.com/3.jpg"))
+		assert.NoError(t, c.Release(Downloaded, "http://c.com/3.jpg"))
+		ok, err = c.Has(Fetched, "http://c.com/3.jpg")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// URLs longer than the maximum key size of the database are stored by hash and truncated in the entry
+		long := "data:image/png;base64," + strings.Repeat("A", 64*1024)
+		claimed, err = c.Claim(Fetched, long, long)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{long}, claimed)
+		ok, err = c.Has(Fetched, long)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		buf.Reset()
+		assert.NoError(t, c.Export(&buf))
+		assert.Less(t, buf.Len(), 4*1024)
+
+		removed, err := c.Clear(true)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, removed)
+
+		removed, err = c.Clear(false)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, removed)
+
+		assert.NoError(t, c.Close())
+	}
+}
@@ -0,0 +1,153 @@
+// Package cache provides URL de-duplication stores shared across pipeline stages
+/*
+ * File: memory.go
+ * Project: cache
+ * File Created: Saturday, 17th October 2026 5:35:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:51:24 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cache
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryCache is an in-memory Cache whose entries do not persist across runs
+type memoryCache struct {
+	ttl     time.Duration
+	entries map[string]*Entry
+	sync.Mutex
+}
+
+// NewMemory is a function for initializing a new in-memory Cache.
+// Entries older than ttl are treated as absent; a ttl of 0 disables expiry.
+func NewMemory(ttl time.Duration) Cache {
+	return &memoryCache{
+		ttl:     ttl,
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Has reports whether url has been recorded at stage or a later stage and has not expired
+func (c *memoryCache) Has(stage Stage, url string) (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[entryKey(NormalizeURL(url))]
+	return ok && e.has(stage) && !e.expired(c.ttl, time.Now()), nil
+}
+
+// Add records urls at stage
+func (c *memoryCache) Add(stage Stage, urls ...string) error {
+	_, err := c.record(stage, urls, false)
+	return err
+}
+
+// Claim records urls at stage and returns those not already recorded at stage or a later stage
+func (c *memoryCache) Claim(stage Stage, urls ...string) ([]string, error) {
+	return c.record(stage, urls, true)
+}
+
+// Release reverts the Claim of urls at stage
+func (c *memoryCache) Release(stage Stage, urls ...string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, u := range urls {
+		key := entryKey(NormalizeURL(u))
+		if e, ok := c.entries[key]; ok && e.unmark(stage) {
+			delete(c.entries, key)
+		}
+	}
+
+	return nil
+}
+
+func (c *memoryCache) record(stage Stage, urls []string, claim bool) ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	claimed := []string{}
+
+	for _, u := range urls {
+		normalized := NormalizeURL(u)
+		key := entryKey(normalized)
+
+		e, ok := c.entries[key]
+		if !ok || e.expired(c.ttl, now) {
+			e = newEntry(normalized)
+			c.entries[key] = e
+		} else if claim && e.has(stage) {
+			continue
+		}
+
+		e.mark(stage, now)
+		claimed = append(claimed, u)
+	}
+
+	return claimed, nil
+}
+
+// Stats returns the cache statistics
+func (c *memoryCache) Stats() (*Stats, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	stats := &Stats{}
+	for _, e := range c.entries {
+		stats.count(e, c.ttl, now)
+	}
+
+	return stats, nil
+}
+
+// Clear removes all or only the expired entries from the cache
+func (c *memoryCache) Clear(expiredOnly bool) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for k, e := range c.entries {
+		if !expiredOnly || e.expired(c.ttl, now) {
+			delete(c.entries, k)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// Export writes all cache entries to w as JSON Lines, ordered by key
+func (c *memoryCache) Export(w io.Writer) error {
+	c.Lock()
+	defer c.Unlock()
+
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	enc := json.NewEncoder(w)
+	for _, k := range keys {
+		if err := enc.Encode(c.entries[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close is a no-op for the in-memory Cache
+func (c *memoryCache) Close() error {
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package cli provides the Cobra CLI commands
+/*
+ * File: augment.go
+ * Project: cli
+ * File Created: Saturday, 17th October 2026 6:43:06 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:02:49 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/worker"
+)
+
+// augmentManifestName is the default name of the manifest of generated variants
+const augmentManifestName = "augment-manifest.jsonl"
+
+// augmentRecord is the JSON Lines record of a generated variant written to the augment manifest
+type augmentRecord struct {
+	InputPath    string              `json:"input_path"`
+	Path         string              `json:"path,omitempty"`
+	Variant      int                 `json:"variant"`
+	Seed         int64               `json:"seed"`
+	Augmentation *image.Augmentation `json:"augmentation,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// augmentCmd represents the augment command
+var augmentCmd = &cobra.Command{
+	Use:   "augment <image path>...",
+	Short: "Generates randomly augmented variants of images",
+	Long: `Generates '--variants' randomly augmented variants of each image for training sets.
+
+Each variant draws its transforms from the enabled ranges, applied in this order:
+  --crop        Random crop keeping at least this fraction of each side e.g. 0.8
+  --flip-h      Probability of mirroring left to right
+  --flip-v      Probability of mirroring top to bottom
+  --rotate-90   Rotation by a random multiple of 90 degrees
+  --rotate      Random rotation up to this many degrees either way; corners are filled with black
+  --brightness  Random brightness change up to this percentage either way
+  --contrast    Random contrast change up to this percentage either way
+  --saturation  Random saturation change up to this percentage either way
+  --blur        Gaussian blur with a random sigma up to this value
+  --noise       Gaussian noise with a random standard deviation up to this value (0-255)
+
+Variants are written as '<name>_aug<index><ext>' next to their image, or into '--path', in '--format'
+or by default the format of the image, or PNG for formats which can only be read e.g. WebP. The
+transforms of every variant are recorded as JSON Lines in '--manifest' (default '` + augmentManifestName + `'
+in '--path'); '--manifest' is required when variants are written next to their images. Variants are
+seeded from '--seed', the input path and the variant index, so a run is reproducible with the same seed;
+the seed is random unless supplied and recorded in the manifest.
+
+With '--stream', input lines are either plain image paths or JSON records as output by
+'download --output jsonl' or 'image --output jsonl'. The variant paths are output to STDOUT.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		streamInput, _ := cmd.Flags().GetBool("stream")
+		workers, _ := cmd.Flags().GetInt("workers")
+		variants, _ := cmd.Flags().GetInt("variants")
+		seed, _ := cmd.Flags().GetInt64("seed")
+		outpath, _ := cmd.Flags().GetString("path")
+		format, _ := cmd.Flags().GetString("format")
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		jpegQuality, _ := cmd.Flags().GetInt("jpeg-quality")
+		silent, _ := cmd.Flags().GetBool("silent")
+
+		opts := &image.AugmentOptions{}
+		opts.FlipH, _ = cmd.Flags().GetFloat64("flip-h")
+		opts.FlipV, _ = cmd.Flags().GetFloat64("flip-v")
+		opts.Rotate90, _ = cmd.Flags().GetBool("rotate-90")
+		opts.Rotate, _ = cmd.Flags().GetFloat64("rotate")
+		opts.Crop, _ = cmd.Flags().GetFloat64("crop")
+		opts.Brightness, _ = cmd.Flags().GetFloat64("brightness")
+		opts.Contrast, _ = cmd.Flags().GetFloat64("contrast")
+		opts.Saturation, _ = cmd.Flags().GetFloat64("saturation")
+		opts.Blur, _ = cmd.Flags().GetFloat64("blur")
+		opts.Noise, _ = cmd.Flags().GetFloat64("noise")
+
+		// Check if any positional args supplied if not streaming input
+		if !streamInput && len(args) == 0 {
+			log.Error("Non-streaming input with 0 length args; exiting")
+			os.Exit(1)
+		}
+
+		if outpath == "" && manifestPath == "" {
+			log.Error("Variants written next to their images require a '--manifest' path; set '--path' or '--manifest'")
+			os.Exit(1)
+		}
+
+		if variants < 1 {
+			log.Errorf("Invalid number of variants %d; must be at least 1", variants)
+			os.Exit(1)
+		}
+		if err := opts.Validate(); err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+		if jpegQuality < 1 || jpegQuality > 100 {
+			log.Errorf("Invalid JPEG quality %d; must be between 1 and 100", jpegQuality)
+			os.Exit(1)
+		}
+		encoding := &image.EncodeOptions{JPEGQuality: jpegQuality}
+
+		var contentType string
+		if format != "" {
+			f, ok := image.LookupFormat(format)
+			if !ok || !f.CanEncode() {
+				log.Errorf("Unsupported format '%s'; must be one of %s", format, strings.Join(image.EncodableTypes(), ", "))
+				os.Exit(1)
+			}
+			contentType = f.ContentType
+		}
+
+		if !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+		}
+		log.Infof("Augmenting with seed %d", seed)
+
+		if outpath != "" {
+			if err := os.MkdirAll(outpath, 0755); err != nil {
+				log.Errorf("Error creating output directory: %s", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		// Record the variants next to them in the output directory
+		if manifestPath == "" {
+			manifestPath = filepath.Join(outpath, augmentManifestName)
+		}
+		manifest, err := os.OpenFile(manifestPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Errorf("Error opening manifest: %s", err.Error())
+			os.Exit(1)
+		}
+		defer manifest.Close()
+
+		// Generate the variants of an input image
+		augment := func(ctx context.Context, item interface{}) (interface{}, error) {
+			inpath := item.(string)
+			records := make([]*augmentRecord, variants)
+			for i := range records {
+				records[i] = &augmentRecord{InputPath: inpath, Variant: i, Seed: seed}
+			}
+
+			fail := func(err error) (interface{}, error) {
+				for _, r := range records {
+					r.Path, r.Augmentation, r.Error = "", nil, err.Error()
+				}
+				return records, err
+			}
+
+			imgBytes, err := ioutil.ReadFile(inpath)
+			if err != nil {
+				return fail(err)
+			}
+			stats, err := image.GetStats(imgBytes)
+			if err != nil {
+				return fail(err)
+			}
+
+			augs := make([]*image.Augmentation, variants)
+			for i := range augs {
+				rng := rand.New(rand.NewSource(image.AugmentSeed(seed, inpath, i)))
+				augs[i] = image.RandomAugmentation(rng, opts, stats.Width, stats.Height)
+			}
+
+			imgs, variantType, err := image.AugmentImage(ctx, imgBytes, augs, contentType, encoding)
+			if err != nil {
+				return fail(err)
+			}
+
+			dir := outpath
+			if dir == "" {
+				dir = filepath.Dir(inpath)
+			}
+			f, _ := image.LookupFormat(variantType)
+			base := strings.TrimSuffix(filepath.Base(inpath), filepath.Ext(inpath))
+
+			for i, r := range records {
+				r.Augmentation = augs[i]
+				r.Path = filepath.Join(dir, fmt.Sprintf("%s_aug%d%s", base, i, f.Extension))
+				if err := ioutil.WriteFile(r.Path, imgs[i], 0666); err != nil {
+					r.Path, r.Error = "", err.Error()
+				}
+			}
+
+			return records, nil
+		}
+
+		pool := worker.NewPool(context.Background(), augment, worker.Options{
+			Workers:    workers,
+			QueueSize:  100,
+			OutputSize: 100,
+			Ordered:    true,
+		})
+		pool.Start()
+		defer pool.Stop()
+
+		// Queue up input paths; the pool is drained once the input is exhausted
+		go func() {
+			defer pool.Close()
+
+			if !streamInput {
+				for _, inpath := range args {
+					if pool.Submit(strings.TrimSpace(inpath)) != nil {
+						return
+					}
+				}
+				return
+			}
+
+			scanner := newInputScanner()
+			for scanner.Scan() {
+				if strings.TrimSpace(scanner.Text()) == "" {
+					continue
+				}
+
+				inpath, rec, err := parseImageInput(scanner.Text())
+				if err != nil {
+					log.Infof("error processing input input=%s, error=%v", scanner.Text(), err)
+					continue
+				}
+				if rec != nil && (rec.Error != "" || rec.Skipped || rec.Path == "") {
+					continue
+				}
+
+				if pool.Submit(inpath) != nil {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				log.Errorf("error reading input: %s", err.Error())
+			}
+		}()
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+		generated, failed := 0, 0
+		results := pool.Results()
+
+		for results != nil {
+			select {
+			case r, ok := <-results:
+				if !ok {
+					results = nil
+					continue
+				}
+
+				records, _ := r.Value.([]*augmentRecord)
+				if r.Err != nil {
+					log.Errorf("error augmenting image '%s': %s", r.Item.(string), r.Err.Error())
+				}
+
+				for _, rec := range records {
+					if rec.Error != "" {
+						failed++
+					} else {
+						generated++
+						if !silent {
+							fmt.Fprint(os.Stdout, rec.Path+"\n")
+						}
+					}
+
+					b, err := json.Marshal(rec)
+					if err == nil {
+						_, err = manifest.Write(append(b, '\n'))
+					}
+					if err != nil {
+						log.Errorf("error writing manifest: %s", err.Error())
+					}
+				}
+
+			case <-sigs:
+				log.Info("Received shutdown signal, exiting")
+				return
+			}
+		}
+
+		log.Infof("finished: generated: %d, failed: %d", generated, failed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(augmentCmd)
+
+	// Optional args
+	augmentCmd.Flags().IntP("workers", "w", 4, "Number of workers to augment images")
+	augmentCmd.Flags().IntP("variants", "k", 5, "Number of variants to generate per image")
+	augmentCmd.Flags().Int64("seed", 0, "Seed of the random transforms (random if not set)")
+	augmentCmd.Flags().StringP("path", "p", "", "Path to output variants (default next to each image)")
+	augmentCmd.Flags().StringP("format", "f", "", "Format of the variants (jpeg, png, gif, bmp, tiff; default the image format, or png for webp)")
+	augmentCmd.Flags().String("manifest", "", "Path of the JSON Lines manifest recording the transforms of each variant (default in --path; required without it)")
+	augmentCmd.Flags().Int("jpeg-quality", jpeg.DefaultQuality, "JPEG quality from 1 to 100")
+	augmentCmd.Flags().Float64("flip-h", 0.5, "Probability of mirroring left to right")
+	augmentCmd.Flags().Float64("flip-v", 0, "Probability of mirroring top to bottom")
+	augmentCmd.Flags().Bool("rotate-90", false, "Rotate by a random multiple of 90 degrees")
+	augmentCmd.Flags().Float64("rotate", 0, "Maximum random rotation in degrees")
+	augmentCmd.Flags().Float64("crop", 1, "Minimum fraction of each side kept by a random crop (1 for no cropping)")
+	augmentCmd.Flags().Float64("brightness", 0, "Maximum random brightness change in percent")
+	augmentCmd.Flags().Float64("contrast", 0, "Maximum random contrast change in percent")
+	augmentCmd.Flags().Float64("saturation", 0, "Maximum random saturation change in percent")
+	augmentCmd.Flags().Float64("blur", 0, "Maximum random Gaussian blur sigma")
+	augmentCmd.Flags().Float64("noise", 0, "Maximum random Gaussian noise standard deviation (0-255)")
+	augmentCmd.Flags().Bool("stream", false, "Streaming input")
+	augmentCmd.Flags().Bool("silent", false, "Do not output variant filepaths")
+}
@@ -0,0 +1,133 @@
+// Package cli provides the Cobra CLI commands
+/*
+ * File: cache.go
+ * Project: cli
+ * File Created: Saturday, 17th October 2026 5:36:35 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:36:35 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/cache"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the persistent URL de-duplication cache.",
+	Long: `Manage the persistent URL de-duplication cache shared by the 'fetch' and 'download' commands.
+
+When '--cache-path' is supplied to 'fetch', image URLs already fetched or downloaded in
+previous runs are not emitted again. When supplied to 'download', URLs already downloaded
+are skipped. Pass the same '--cache-path' to both commands to share a single cache.`,
+}
+
+// cacheStatsCmd represents the cache stats command
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Output cache statistics as JSON.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := mustOpenCache(cmd)
+		defer c.Close()
+
+		stats, err := c.Stats()
+		if err != nil {
+			log.Errorf("Error reading cache: %s", err.Error())
+			os.Exit(1)
+		}
+
+		j, _ := json.MarshalIndent(stats, "", "\t")
+		fmt.Fprint(os.Stdout, string(j)+"\n")
+	},
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove entries from the cache.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		expiredOnly, _ := cmd.Flags().GetBool("expired")
+
+		c := mustOpenCache(cmd)
+		defer c.Close()
+
+		removed, err := c.Clear(expiredOnly)
+		if err != nil {
+			log.Errorf("Error clearing cache: %s", err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stdout, "removed %d entries\n", removed)
+	},
+}
+
+// cacheExportCmd represents the cache export command
+var cacheExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export cache entries to STDOUT as JSON Lines.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := mustOpenCache(cmd)
+		defer c.Close()
+
+		if err := c.Export(os.Stdout); err != nil {
+			log.Errorf("Error exporting cache: %s", err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd, cacheExportCmd)
+
+	// Required args
+	addCacheFlags(cacheCmd.PersistentFlags())
+	cacheCmd.MarkPersistentFlagRequired("cache-path")
+
+	// Optional args
+	cacheClearCmd.Flags().Bool("expired", false, "Only remove expired entries")
+}
+
+// addCacheFlags is a helper function for registering the cache flags shared by commands
+func addCacheFlags(flags *pflag.FlagSet) {
+	flags.String("cache-path", "", "Path to the persistent URL cache file")
+	flags.Duration("cache-ttl", 0, "Expiry of cache entries e.g. '168h' (0 for no expiry)")
+}
+
+// openCache is a helper function for opening the persistent cache configured by the cache flags.
+// Returns nil if no cache path is configured.
+func openCache(cmd *cobra.Command) (cache.Cache, error) {
+	path, _ := cmd.Flags().GetString("cache-path")
+	ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+
+	if path == "" {
+		return nil, nil
+	}
+
+	return cache.Open(path, ttl)
+}
+
+// mustOpenCache is a helper function for opening the persistent cache, exiting on failure
+func mustOpenCache(cmd *cobra.Command) cache.Cache {
+	c, err := openCache(cmd)
+	if err != nil {
+		log.Errorf("Error opening cache: %s", err.Error())
+		os.Exit(1)
+	}
+
+	return c
+}
@@ -0,0 +1,337 @@
+// Package cli provides the Cobra CLI commands
+/*
+ * File: dedupe.go
+ * Project: cli
+ * File Created: Saturday, 17th October 2026 5:39:27 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:04:47 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/worker"
+)
+
+const (
+	dedupeActionReport     = "report"
+	dedupeActionQuarantine = "quarantine"
+	dedupeActionDelete     = "delete"
+)
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe <directory>",
+	Short: "Find and clean up exact and near-duplicate images.",
+	Long: `Groups exact (MD5) and near-duplicate (perceptual hash) images into clusters and keeps the
+best member of each cluster according to --keep (resolution, filesize or first, the first in the input
+order). An image only joins a cluster if it is within --threshold of every member.
+
+Actions (--action):
+  report      Output the clusters as JSON or CSV (--report-format) without modifying any files
+  quarantine  Move all but the kept member of each cluster into --quarantine
+  delete      Delete all but the kept member of each cluster
+
+By default the images in <directory> are walked recursively and the clusters are reported to STDOUT.
+If the '--stream' option is supplied, image paths are read from STDIN and the paths of accepted images
+are output to STDOUT as they are processed, allowing 'dedupe' to follow 'image' in a pipeline. Note that
+in streaming mode, a previously output image may later be displaced by a better duplicate. Use --report
+to additionally write the final clusters to a file.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		streamInput, _ := cmd.Flags().GetBool("stream")
+		workers, _ := cmd.Flags().GetInt("workers")
+		action, _ := cmd.Flags().GetString("action")
+		quarantine, _ := cmd.Flags().GetString("quarantine")
+		reportPath, _ := cmd.Flags().GetString("report")
+		reportFormat, _ := cmd.Flags().GetString("report-format")
+		keep, _ := cmd.Flags().GetString("keep")
+		hashName, _ := cmd.Flags().GetString("hash")
+		threshold, _ := cmd.Flags().GetInt("threshold")
+		exactOnly, _ := cmd.Flags().GetBool("exact-only")
+		silent, _ := cmd.Flags().GetBool("silent")
+
+		// Check if any positional args supplied if not streaming input
+		if !streamInput && len(args) == 0 {
+			log.Error("Non-streaming input with 0 length args; exiting")
+			os.Exit(1)
+		}
+
+		if workers < 1 {
+			log.Errorf("Invalid number of workers %d; must be at least 1", workers)
+			os.Exit(1)
+		}
+
+		policy, err := image.ParseKeepPolicy(keep)
+		if err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+
+		kind, err := image.ParseHashKind(hashName)
+		if err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if exactOnly {
+			threshold = -1
+		}
+
+		switch action {
+		case dedupeActionReport, dedupeActionDelete:
+		case dedupeActionQuarantine:
+			if quarantine == "" {
+				log.Error("Quarantine action requires a --quarantine directory; exiting")
+				os.Exit(1)
+			}
+			if err := os.MkdirAll(quarantine, 0755); err != nil {
+				log.Errorf("Error creating quarantine directory: %s", err.Error())
+				os.Exit(1)
+			}
+		default:
+			log.Errorf("Unrecognized action '%s'; exiting", action)
+			os.Exit(1)
+		}
+
+		if reportFormat != "json" && reportFormat != "csv" {
+			log.Errorf("Unrecognized report format '%s'; exiting", reportFormat)
+			os.Exit(1)
+		}
+
+		// Streaming output occupies STDOUT
+		if reportPath == "" && !streamInput {
+			reportPath = "-"
+		}
+
+		// Hash images concurrently; results are clustered sequentially in input order
+		hashImage := func(ctx context.Context, item interface{}) (interface{}, error) {
+			p := item.(string)
+			imgBytes, err := ioutil.ReadFile(p)
+			if err != nil {
+				log.Infof("error processing input input=%s, error=%v", p, err)
+				return nil, err
+			}
+
+			e, err := image.NewDedupeEntry(p, imgBytes, kind)
+			if err != nil {
+				log.Infof("skipping undecodable image input=%s, error=%v", p, err)
+				return nil, err
+			}
+			return e, nil
+		}
+
+		pool := worker.NewPool(context.Background(), hashImage, worker.Options{
+			Workers:    workers,
+			QueueSize:  100,
+			OutputSize: 100,
+			Ordered:    true,
+		})
+		pool.Start()
+		defer pool.Stop()
+
+		// Queue up input paths; the pool is drained once the input is exhausted
+		go func() {
+			defer pool.Close()
+
+			if streamInput {
+				scanner := bufio.NewScanner(os.Stdin)
+				for scanner.Scan() {
+					if p := strings.TrimSpace(scanner.Text()); p != "" {
+						if pool.Submit(p) != nil {
+							return
+						}
+					}
+				}
+				return
+			}
+
+			quarantineAbs, _ := filepath.Abs(quarantine)
+			err := filepath.Walk(args[0], func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					log.Warnf("error walking path=%s, error=%v", p, err)
+					return nil
+				}
+
+				// Do not re-process quarantined images
+				if info.IsDir() {
+					if abs, _ := filepath.Abs(p); quarantine != "" && abs == quarantineAbs {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+
+				return pool.Submit(p)
+			})
+			if err != nil {
+				log.Errorf("error walking directory: %s", err.Error())
+			}
+		}()
+
+		deduper := image.NewDeduper(kind, threshold, policy)
+		removed := 0
+
+		for r := range pool.Results() {
+			if r.Err != nil {
+				continue
+			}
+			e := r.Value.(*image.DedupeEntry)
+			e.Seq = int(r.Index)
+			displaced := deduper.Add(e)
+
+			for _, d := range displaced {
+				log.Infof("duplicate: path=%s", d.Path)
+			}
+
+			if streamInput {
+				if err := applyDedupeAction(action, quarantine, displaced); err != nil {
+					log.Error(err.Error())
+				}
+				removed += len(displaced)
+
+				// Output the image if it is currently kept
+				kept := true
+				for _, d := range displaced {
+					kept = kept && d != e
+				}
+				if kept && !silent {
+					fmt.Fprint(os.Stdout, e.Path+"\n")
+				}
+			}
+
+			if deduper.Len()%100 == 0 {
+				log.Infof("processed: %d", deduper.Len())
+			}
+		}
+
+		clusters := deduper.Clusters()
+
+		// Batch actions are applied once all clusters are known
+		if !streamInput {
+			for _, c := range clusters {
+				if err := applyDedupeAction(action, quarantine, c.Duplicates); err != nil {
+					log.Error(err.Error())
+				}
+				removed += len(c.Duplicates)
+			}
+		}
+
+		log.Infof("processed %d images; %d clusters; %d duplicates", deduper.Len(), len(clusters), removed)
+
+		if reportPath != "" {
+			if err := writeDedupeReport(reportPath, reportFormat, clusters); err != nil {
+				log.Errorf("Error writing report: %s", err.Error())
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	// Optional args
+	dedupeCmd.Flags().IntP("workers", "w", 4, "Number of workers to hash images")
+	dedupeCmd.Flags().String("action", dedupeActionReport, "Action to apply to duplicates (report, quarantine, delete)")
+	dedupeCmd.Flags().String("quarantine", "", "Directory to move duplicates into")
+	dedupeCmd.Flags().String("report", "", "Path to write the cluster report ('-' for STDOUT)")
+	dedupeCmd.Flags().String("report-format", "json", "Cluster report format (json, csv)")
+	dedupeCmd.Flags().String("keep", "resolution", "Policy for the image to keep (resolution, filesize, first)")
+	dedupeCmd.Flags().String("hash", "phash", "Perceptual hash algorithm (ahash, dhash, phash)")
+	dedupeCmd.Flags().Int("threshold", image.DefaultHashThreshold, "Maximum hash distance of near-duplicates")
+	dedupeCmd.Flags().Bool("exact-only", false, "Only detect byte-for-byte identical duplicates")
+	dedupeCmd.Flags().Bool("stream", false, "Streaming input")
+	dedupeCmd.Flags().Bool("silent", false, "Do not output accepted filepaths")
+}
+
+// applyDedupeAction is a helper function for quarantining or deleting duplicate images
+func applyDedupeAction(action, quarantine string, duplicates []*image.DedupeEntry) error {
+	for _, d := range duplicates {
+		switch action {
+		case dedupeActionQuarantine:
+			dst := uniquePath(filepath.Join(quarantine, filepath.Base(d.Path)))
+			if err := os.Rename(d.Path, dst); err != nil {
+				return fmt.Errorf("error quarantining duplicate '%s': %s", d.Path, err.Error())
+			}
+		case dedupeActionDelete:
+			if err := os.Remove(d.Path); err != nil {
+				return fmt.Errorf("error deleting duplicate '%s': %s", d.Path, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// uniquePath is a helper function for suffixing a filepath with a counter until it does not exist
+func uniquePath(p string) string {
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+
+	for i := 1; ; i++ {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			return p
+		}
+		p = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// writeDedupeReport is a helper function for writing the cluster report to a file or STDOUT
+func writeDedupeReport(reportPath, format string, clusters []*image.Cluster) error {
+	var w io.Writer = os.Stdout
+	if reportPath != "-" {
+		f, err := os.Create(reportPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		j, err := json.MarshalIndent(clusters, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, string(j)+"\n")
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"cluster", "role", "path", "size", "width", "height", "md5", "hash"})
+	for i, c := range clusters {
+		rows := []*image.DedupeEntry{c.Keep}
+		rows = append(rows, c.Duplicates...)
+		for j, e := range rows {
+			role := "duplicate"
+			if j == 0 {
+				role = "keep"
+			}
+			cw.Write([]string{
+				strconv.Itoa(i), role, e.Path, strconv.Itoa(e.Size),
+				strconv.Itoa(e.Width), strconv.Itoa(e.Height), e.MD5, e.Hash,
+			})
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
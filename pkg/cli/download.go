@@ -5,13 +5,13 @@
  * File Created: Tuesday, 24th March 2020 6:36:35 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Wednesday, 5th January 2022 12:06:07 pm
+ * Last Modified: Saturday, 17th October 2026 7:02:23 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package cli
 
 import (
-	"bufio"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"os/signal"
@@ -21,7 +21,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/download"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
 )
 
 // downloadCmd represents the download command
@@ -29,9 +32,50 @@ var downloadCmd = &cobra.Command{
 	Use:   "download <URIs>",
 	Short: "Download content from URI.",
 	Long: `Download URIs using a raw, comma delimited string of URIs as input. 
-	If the '--stream' option is supplied, the tool will run and accept URIs until terminated.
+	If the '--stream' option is supplied, the tool will accept URIs from STDIN until EOF,
+	then finish the queued downloads, log a processed/failed summary and exit.
 	Downloads content to the directory specified by --outpath (defaults to current directory).
-	Outputs filepaths to STDOUT unless --silent option is specified.`,
+	Outputs filepaths to STDOUT unless --silent option is specified.
+	With '--output jsonl', one JSON record is output per URL instead, including failed and skipped URLs,
+	with the source and sanitized URL, path, size, content type, dimensions, SHA-256 hash and error.
+
+	Files are never overwritten. The '--naming' option selects how files are named:
+	  basename  sanitized basename of the URL; colliding names are suffixed e.g. 'image_1.jpg' (default)
+	  hash      SHA-256 of the file contents with the extension of the sniffed content type
+	  template  the '--template' e.g. '{host}/{hash8}_{basename}{ext}' or '{query}/{index}'
+	            Placeholders: {host}, {hash}, {hash8}, {basename}, {ext}, {query} (see '--query'), {index}
+	'--shard-depth N' spreads files across N levels of subdirectories taken from the content hash
+	e.g. 'ab/cd/image.jpg', so no single directory holds millions of files.
+
+	The outcome of every URL is recorded in a manifest (` + download.ManifestName + `) in the output directory.
+	With '--resume', URLs the manifest records as completed are skipped, so an interrupted run can be restarted.
+	'--retry-failed' ignores the input URIs and replays only the URLs the manifest records as failed; data URIs
+	are recorded by the hash of their contents, so failed data URIs can't be retried and must be input again.
+
+	Downloads are streamed to a temporary file and only moved into place once complete. Files larger than
+	'--max-bytes' are rejected up front by their Content-Length (or a HEAD request with '--head-check') or
+	aborted mid-stream, and files whose sniffed content type is not in '--allow-type' e.g. 'image/*' are
+	rejected before anything is written. With '--validate', images are fully decoded before they are accepted;
+	corrupt, truncated and zero-pixel images and decompression bombs larger than '--max-pixels' are rejected.
+
+	URLs resolving to private, loopback, link-local (including cloud metadata) and other non-public
+	addresses are refused, including on redirects. Trust internal hosts explicitly with '--trusted-host'
+	e.g. 'minio.internal,*.corp.example.com,10.1.0.0/16', or disable the protection with '--allow-private'.
+
+	Inline 'data:' URIs e.g. 'data:image/png;base64,iVBOR...' are decoded and stored like any download.
+	They are recorded in the manifest and cache by the hash of their contents ('data:sha256:<hex>') and
+	shortened in logs and output records.
+	Local files can be run through the same checks, naming and manifest as 'file:///abs/path.jpg' URLs
+	with '--allow-file'; they are refused by default so URLs from search results cannot copy local files.
+
+	Requests time out, and network errors, 429 and 5xx responses are retried with exponential backoff
+	honoring Retry-After. Requests to a single host are capped by '--host-concurrency' and spaced by
+	'--host-delay'; a host failing '--breaker-threshold' times in a row is skipped for '--breaker-cooldown'.
+	These settings may also be set in the config file under the 'download' key e.g.
+
+	  download:
+	    host-concurrency: 2
+	    host-delay: 500ms`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		outpath, _ := cmd.Flags().GetString("path")
@@ -39,10 +83,63 @@ var downloadCmd = &cobra.Command{
 		b64Encoded, _ := cmd.Flags().GetBool("b64")
 		streamInput, _ := cmd.Flags().GetBool("stream")
 		silent, _ := cmd.Flags().GetBool("silent")
+		naming, _ := cmd.Flags().GetString("naming")
+		template, _ := cmd.Flags().GetString("template")
+		shardDepth, _ := cmd.Flags().GetInt("shard-depth")
+		query, _ := cmd.Flags().GetString("query")
+		noManifest, _ := cmd.Flags().GetBool("no-manifest")
+		resume, _ := cmd.Flags().GetBool("resume")
+		retryFailed, _ := cmd.Flags().GetBool("retry-failed")
+		maxBytes, _ := cmd.Flags().GetInt64("max-bytes")
+		allowTypes, _ := cmd.Flags().GetStringSlice("allow-type")
+		headCheck, _ := cmd.Flags().GetBool("head-check")
+		allowFiles, _ := cmd.Flags().GetBool("allow-file")
+		validate, _ := cmd.Flags().GetBool("validate")
+		maxPixels, _ := cmd.Flags().GetInt64("max-pixels")
+		output, _ := cmd.Flags().GetString("output")
+		queueSize, _ := cmd.Flags().GetInt("queue-size")
+		taskTimeout, _ := cmd.Flags().GetDuration("task-timeout")
+		ordered, _ := cmd.Flags().GetBool("ordered")
+
+		if err := checkOutputMode(output); err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+		allowPrivate, _ := cmd.Flags().GetBool("allow-private")
+		trustedHosts, _ := cmd.Flags().GetStringSlice("trusted-host")
+
+		if noManifest && (resume || retryFailed) {
+			log.Error("'--resume' and '--retry-failed' require the download manifest; remove '--no-manifest'")
+			os.Exit(1)
+		}
+
+		// Record the outcome of each URL in the output directory
+		var manifest *download.Manifest
+		if !noManifest {
+			var err error
+			if manifest, err = download.OpenManifestIn(outpath); err != nil {
+				log.Errorf("Error opening download manifest: %s", err.Error())
+				os.Exit(1)
+			}
+			defer manifest.Close()
+		}
 
 		// Check if any positional args supplied if not streaming input
 		urls := []string{}
-		if !streamInput {
+		if retryFailed {
+			// Replay failed URLs in place of the input
+			streamInput = false
+			for _, u := range manifest.Failed() {
+				if b64Encoded {
+					u = base64.StdEncoding.EncodeToString([]byte(u))
+				}
+				urls = append(urls, u)
+			}
+			if len(urls) == 0 {
+				log.Info("No failed downloads to retry")
+				return
+			}
+		} else if !streamInput {
 			if len(args) == 0 {
 				log.Error("Non-streaming input with 0 length args; exiting")
 				os.Exit(1)
@@ -57,6 +154,57 @@ var downloadCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		downloader.Namer, err = download.NewNamer(naming, template)
+		if err != nil {
+			log.Errorf("Error initializing file naming: %s", err.Error())
+			os.Exit(1)
+		}
+		downloader.QueueSize = queueSize
+		downloader.TaskTimeout = taskTimeout
+		downloader.Ordered = ordered
+		downloader.ShardDepth = shardDepth
+		downloader.Query = query
+		downloader.Manifest = manifest
+		downloader.Limits = &download.Limits{
+			MaxBytes:     maxBytes,
+			AllowedTypes: allowTypes,
+			HeadCheck:    headCheck,
+			AllowFiles:   allowFiles,
+		}
+		var guard *download.Guard
+		if !allowPrivate {
+			if guard, err = download.NewGuard(trustedHosts...); err != nil {
+				log.Errorf("Error initializing address guard: %s", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		downloader.Client = download.NewClient(&download.ClientOptions{
+			ConnectTimeout: viper.GetDuration("download.connect-timeout"),
+			ReadTimeout:    viper.GetDuration("download.read-timeout"),
+			Timeout:        viper.GetDuration("download.timeout"),
+			Retry: throttle.Policy{
+				MaxRetries: viper.GetInt("download.retries"),
+				BaseDelay:  viper.GetDuration("download.backoff"),
+				MaxDelay:   viper.GetDuration("download.backoff-max"),
+			},
+			HostConcurrency:  viper.GetInt("download.host-concurrency"),
+			HostDelay:        viper.GetDuration("download.host-delay"),
+			BreakerThreshold: viper.GetInt("download.breaker-threshold"),
+			BreakerCooldown:  viper.GetDuration("download.breaker-cooldown"),
+			UserAgent:        viper.GetString("download.user-agent"),
+			Guard:            guard,
+		})
+		downloader.ValidateImages = validate
+		downloader.MaxPixels = maxPixels
+		downloader.Resume = resume || retryFailed
+
+		// Skip URLs downloaded in previous runs
+		if c := mustOpenCache(cmd); c != nil {
+			defer c.Close()
+			downloader.Cache = c
+		}
+
 		// Kick off downloader worker routines
 		err = downloader.Start()
 		if err != nil {
@@ -65,23 +213,35 @@ var downloadCmd = &cobra.Command{
 		}
 		defer downloader.Stop()
 
-		// Kick off an input streamer routine
-		if streamInput {
-			go func() {
-				scanner := bufio.NewScanner(os.Stdin)
+		// Kick off an input routine which drains the downloader once the input is exhausted
+		summaries := make(chan *download.Summary, 1)
+		go func() {
+			defer func() {
+				summaries <- downloader.Close()
+			}()
+
+			if streamInput {
+				scanner := newInputScanner()
 				for scanner.Scan() {
 					url := strings.TrimSpace(scanner.Text())
+					if url == "" {
+						continue
+					}
 					downloader.InChan <- url
 				}
-			}()
-		} else {
-			// Queue up filepaths
+				if err := scanner.Err(); err != nil {
+					log.Errorf("error reading input: %s", err.Error())
+				}
+				return
+			}
+
+			// Queue up URLs
 			for _, url := range urls {
 				downloader.InChan <- url
 			}
-		}
+		}()
 
-		// Wait for all sent filepaths to be processed
+		// Wait for all sent URLs to be processed
 		sent := len(urls)
 		received := 0
 
@@ -90,31 +250,38 @@ var downloadCmd = &cobra.Command{
 
 		for {
 			select {
-			case f := <-downloader.OutChan:
+			case f, ok := <-downloader.OutChan:
+				if !ok {
+					summary := <-summaries
+					log.Infof("finished: %s", summary.String())
+					return
+				}
 				received++
 
 				// Verbose output
-				if received%10 == 0 || sent == received {
+				if streamInput && received%10 == 0 {
+					stats := downloader.Stats()
+					log.Infof("processed: %d (queued: %d, in flight: %d)", received, stats.Queued, stats.InFlight)
+				} else if !streamInput && (received%10 == 0 || sent == received) {
 					log.Infof("processed: %d/%d", received, sent)
 				}
 
 				// Check if unable to process
 				if f.Error != nil {
-					log.Errorf("error processing file: url=%s name=%s type=%s error=%s", f.RawURL, f.Name, f.ContentType, f.Error.Error())
+					log.Errorf("error processing file: url=%s name=%s type=%s error=%s", download.DisplayURL(f.RawURL), f.Name, f.ContentType, f.Error.Error())
+				} else if f.Cached {
+					log.Infof("skipping previously downloaded file: url=%s\n", download.DisplayURL(f.SanitizedURL))
 				} else {
-					log.Infof("downloaded file: url=%s name=%s type=%s size=%d\n", f.SanitizedURL, f.Name, f.ContentType, f.Size)
+					log.Infof("downloaded file: url=%s name=%s type=%s size=%d\n", download.DisplayURL(f.SanitizedURL), f.Name, f.ContentType, f.Size)
 				}
 
-				// Output filepath to stdout
-				if !silent {
+				// Output filepath of newly downloaded files, or a record of every file, to stdout
+				if !silent && output == outputJSONL {
+					writeRecord(newFileRecord(f))
+				} else if !silent && !f.Cached && f.Error == nil {
 					fmt.Fprint(os.Stdout, path.Join(f.Location, f.Name)+"\n")
 				}
 
-				// Only triggers if went supplied URIs as a positional argument
-				if sent == received {
-					return
-				}
-
 			case <-sigs:
 				log.Info("Received shutdown signal, exiting")
 				return
@@ -132,4 +299,44 @@ func init() {
 	downloadCmd.Flags().BoolP("b64", "b", false, "Base64 encoded input")
 	downloadCmd.Flags().Bool("stream", false, "Streaming input")
 	downloadCmd.Flags().Bool("silent", false, "Do not output downloaded filepaths")
+	downloadCmd.Flags().String("output", outputPath, "Output format (path, jsonl)")
+	downloadCmd.Flags().Int("queue-size", 100, "Number of URLs buffered ahead of the workers")
+	downloadCmd.Flags().Duration("task-timeout", 0, "Maximum duration of a single URL including retries (0 for unlimited)")
+	downloadCmd.Flags().Bool("ordered", false, "Output files in the order their URLs were input")
+	downloadCmd.Flags().String("naming", download.NamingBasename, "File naming strategy (basename, hash, template)")
+	downloadCmd.Flags().String("template", "{host}/{hash8}_{basename}{ext}", "File naming template used with '--naming template'")
+	downloadCmd.Flags().Int("shard-depth", 0, "Levels of hash-derived subdirectories to spread files across")
+	downloadCmd.Flags().String("query", "", "Search query the URLs were fetched with; used by the {query} template placeholder")
+	downloadCmd.Flags().Bool("no-manifest", false, "Do not record downloads in the output directory manifest")
+	downloadCmd.Flags().Bool("resume", false, "Skip URLs the manifest records as completed")
+	downloadCmd.Flags().Bool("retry-failed", false, "Retry only the URLs the manifest records as failed")
+	downloadCmd.Flags().Int64("max-bytes", 0, "Maximum file size in bytes (0 for unlimited)")
+	downloadCmd.Flags().StringSlice("allow-type", []string{}, "Allowed sniffed content types e.g. 'image/*' (default all)")
+	downloadCmd.Flags().Bool("head-check", false, "Check the size of each file with a HEAD request before downloading")
+	downloadCmd.Flags().Bool("allow-private", false, "Allow downloads from private, loopback and link-local addresses")
+	downloadCmd.Flags().StringSlice("trusted-host", []string{}, "Internal host names, addresses or CIDR ranges to allow downloads from")
+	downloadCmd.Flags().Bool("allow-file", false, "Allow 'file://' URLs to be read from the local file system")
+	downloadCmd.Flags().Bool("validate", false, "Reject images which fail to decode, are truncated or have zero pixels")
+	downloadCmd.Flags().Int64("max-pixels", image.DefaultMaxPixels, "Maximum pixels of an image accepted with '--validate' (0 for unlimited)")
+
+	// HTTP client args; configurable from the config file e.g. 'download.host-delay: 500ms'
+	clientOpts := download.DefaultClientOptions()
+	downloadCmd.Flags().Duration("connect-timeout", clientOpts.ConnectTimeout, "Timeout for establishing a connection")
+	downloadCmd.Flags().Duration("read-timeout", clientOpts.ReadTimeout, "Timeout for receiving the response headers")
+	downloadCmd.Flags().Duration("timeout", clientOpts.Timeout, "Timeout for a whole download")
+	downloadCmd.Flags().Int("retries", clientOpts.Retry.MaxRetries, "Maximum retries of failed downloads")
+	downloadCmd.Flags().Duration("backoff", clientOpts.Retry.BaseDelay, "Initial retry backoff delay")
+	downloadCmd.Flags().Duration("backoff-max", clientOpts.Retry.MaxDelay, "Maximum retry backoff delay")
+	downloadCmd.Flags().Int("host-concurrency", clientOpts.HostConcurrency, "Maximum in-flight downloads per host (0 for unlimited)")
+	downloadCmd.Flags().Duration("host-delay", clientOpts.HostDelay, "Minimum delay between downloads from the same host")
+	downloadCmd.Flags().Int("breaker-threshold", clientOpts.BreakerThreshold, "Consecutive failures after which a host is skipped (0 to disable)")
+	downloadCmd.Flags().Duration("breaker-cooldown", clientOpts.BreakerCooldown, "Duration a failing host is skipped for")
+	downloadCmd.Flags().String("user-agent", "", "User-Agent header sent with downloads")
+	for _, name := range []string{
+		"connect-timeout", "read-timeout", "timeout", "retries", "backoff", "backoff-max",
+		"host-concurrency", "host-delay", "breaker-threshold", "breaker-cooldown", "user-agent",
+	} {
+		viper.BindPFlag("download."+name, downloadCmd.Flags().Lookup(name))
+	}
+	addCacheFlags(downloadCmd.Flags())
 }
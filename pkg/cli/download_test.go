@@ -0,0 +1,101 @@
+/*
+ * File: download_test.go
+ * Project: cli
+ * File Created: Saturday, 17th October 2026 6:54:12 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:54:12 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/download"
+)
+
+// noiseDataURI is a helper function for creating a PNG data URI of random pixels, which does not compress
+func noiseDataURI(width, height int, seed int64) string {
+	rnd := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rnd.Read(img.Pix)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDownloadLargeDataURI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-cli")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A data URI longer than the default line limit of bufio.Scanner, followed by another input
+	uris := []string{noiseDataURI(200, 200, 1), noiseDataURI(10, 10, 2)}
+	assert.Greater(t, len(uris[0]), 64*1024)
+
+	inpath := filepath.Join(dir, "input.txt")
+	assert.NoError(t, ioutil.WriteFile(inpath, []byte(strings.Join(uris, "\n")+"\n"), 0644))
+	outpath := filepath.Join(dir, "output.jsonl")
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "images"), 0755))
+
+	stdin, err := os.Open(inpath)
+	assert.NoError(t, err)
+	defer stdin.Close()
+	stdout, err := os.Create(outpath)
+	assert.NoError(t, err)
+	defer stdout.Close()
+
+	origStdin, origStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, stdout
+	defer func() { os.Stdin, os.Stdout = origStdin, origStdout }()
+
+	rootCmd.SetArgs([]string{"download", "--stream", "--output", "jsonl", "--ordered", "-p", filepath.Join(dir, "images")})
+	assert.NoError(t, rootCmd.Execute())
+	os.Stdin, os.Stdout = origStdin, origStdout
+
+	// Records hold a shortened URL rather than the whole image
+	out, err := ioutil.ReadFile(outpath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if assert.Len(t, lines, 2) {
+		for i, line := range lines {
+			assert.Less(t, len(line), 1024)
+
+			var r outputRecord
+			assert.NoError(t, json.Unmarshal([]byte(line), &r))
+			assert.Empty(t, r.Error)
+			assert.Equal(t, download.DisplayURL(uris[i]), r.SourceURL)
+			assert.FileExists(t, r.Path)
+		}
+	}
+
+	// As does the manifest
+	manifest, err := os.Open(filepath.Join(dir, "images", download.ManifestName))
+	if assert.NoError(t, err) {
+		defer manifest.Close()
+
+		entries := 0
+		scanner := bufio.NewScanner(manifest)
+		for scanner.Scan() {
+			entries++
+			assert.True(t, strings.HasPrefix(scanner.Text(), `{"url":"data:sha256:`), scanner.Text())
+		}
+		assert.NoError(t, scanner.Err())
+		assert.Equal(t, 2, entries)
+	}
+}
@@ -5,20 +5,26 @@
  * File Created: Sunday, 22nd March 2020 1:40:10 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Wednesday, 5th January 2022 12:06:07 pm
+ * Last Modified: Saturday, 17th October 2026 5:54:49 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"text/tabwriter"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/fetch"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
 )
 
 // fetchCmd represents the fetch command
@@ -34,6 +40,47 @@ E.g. './emerald-cli fetch cat -t images -l english -u https://searx.info/'
 The output of this command is the returned JSON of the content results to stdout. 
 Simply pipe the results to a file is desired:  './emerald-cli fetch cat -t images > out.json
 
+Alternative search backends can be selected with the '--backend' option:
+  searx  Searx instance at --server (default)
+  json   Generic JSON-over-HTTP API at --server, mapped onto the Searx result fields
+         using --json-results and --json-fields e.g.
+         --json-results 'data.items' --json-fields 'img_src=media.url,title=caption'
+  file   Local file at --server containing one URL or JSON encoded result per line
+
+Multiple Searx instances can be supplied to '--server' e.g. '-s http://127.0.0.1:8081,http://127.0.0.1:8082'.
+Queries are spread across the healthy instances; instances failing repeatedly or failing the periodic
+health check are temporarily ejected. Per-instance statistics are output to STDERR at the end of the run.
+
+Queries are retried with exponential backoff on network errors, 429 and 5xx responses, and rate
+limited per Searx endpoint. These settings may also be set in the config file under the 'fetch' key e.g.
+
+  fetch:
+    rate: 1.5
+    retries: 5
+    timeout: 20s
+
+Instead of guessing '--pages', '--target N' keeps paginating across all languages until N unique
+results have been collected. Pagination stops early once '--patience' consecutive pages return no new
+results, or '--max-pages' pages have been fetched. A summary of why it stopped is output to STDERR.
+
+The Searx engines, safe search level and time range can be set with '--engines', '--safesearch' and
+'--time-range'. They are checked against the engines advertised by the Searx '/config' endpoint before
+fetching and recorded in the output JSON e.g. '--engines "bing images" --safesearch strict --time-range year'.
+
+Unwanted results can be filtered out before they are output (and before they are recorded in the
+cache) with the filter options, or with a YAML file passed to '--filters' e.g.
+
+  engine_deny: [pinterest]
+  domain_deny: ["*.shutterstock.com"]
+  title_exclude: ["(?i)clipart"]
+  format_allow: [jpeg, png]
+  min_width: 640
+  min_height: 480
+
+Filter options given on the command line are added to those in the file. Domains are matched against
+both the result page and image source hosts; results with an unknown resolution pass the minimum
+resolution check. The number of results removed by each rule is output to STDERR.
+
 Pro Tip: To quickly check the number of results, use JQ: cat out.json | jq '.resultno'
 Note that the 'resultno' field is only available when batch downloading i.e. not using the 'stream' option`,
 	Args: cobra.ExactArgs(1),
@@ -41,7 +88,8 @@ Note that the 'resultno' field is only available when batch downloading i.e. not
 
 		query := args[0]
 
-		server, _ := cmd.Flags().GetString("server")
+		backend, _ := cmd.Flags().GetString("backend")
+		servers, _ := cmd.Flags().GetStringSlice("server")
 		contentType, _ := cmd.Flags().GetString("type")
 		stream, _ := cmd.Flags().GetBool("stream")
 		pageno, _ := cmd.Flags().GetInt("pageno")
@@ -49,23 +97,158 @@ Note that the 'resultno' field is only available when batch downloading i.e. not
 		languagesAllExt, _ := cmd.Flags().GetBool("all-langs-ext")
 		languagesAllSimple, _ := cmd.Flags().GetBool("all-langs-simple")
 		languages, err := cmd.Flags().GetStringSlice("languages")
+		deadline, _ := cmd.Flags().GetDuration("deadline")
+		target, _ := cmd.Flags().GetInt("target")
+		maxPages, _ := cmd.Flags().GetInt("max-pages")
+		patience, _ := cmd.Flags().GetInt("patience")
+		engines, _ := cmd.Flags().GetStringSlice("engines")
+		safeSearch, _ := cmd.Flags().GetString("safesearch")
+		timeRange, _ := cmd.Flags().GetString("time-range")
+
+		var mapping *fetch.FieldMapping
+		if backend == fetch.BackendJSON {
+			mapping = fetch.DefaultFieldMapping()
+			if cmd.Flags().Changed("json-results") {
+				mapping.Results, _ = cmd.Flags().GetString("json-results")
+			}
+			if cmd.Flags().Changed("json-fields") {
+				mapping.Fields, _ = cmd.Flags().GetStringToString("json-fields")
+			}
+			params, _ := cmd.Flags().GetStringToString("json-params")
+			for k, v := range params {
+				switch k {
+				case "query":
+					mapping.QueryParam = v
+				case "page":
+					mapping.PageParam = v
+				case "lang":
+					mapping.LangParam = v
+				case "category":
+					mapping.CategoryParam = v
+				default:
+					log.Warnf("unrecognized JSON API parameter '%s'; skipping", k)
+				}
+			}
+		}
+
+		// Searx client options may also be set in the config file under the 'fetch' key
+		searxOpts := &fetch.SearxOptions{
+			Timeout: viper.GetDuration("fetch.timeout"),
+			Retry: throttle.Policy{
+				MaxRetries: viper.GetInt("fetch.retries"),
+				BaseDelay:  viper.GetDuration("fetch.backoff"),
+				MaxDelay:   viper.GetDuration("fetch.backoff-max"),
+			},
+			Rate:             viper.GetFloat64("fetch.rate"),
+			Burst:            viper.GetInt("fetch.burst"),
+			FailureThreshold: viper.GetInt("fetch.eject-after"),
+			EjectDuration:    viper.GetDuration("fetch.eject-duration"),
+			HealthInterval:   viper.GetDuration("fetch.health-interval"),
+		}
+
+		searcher, err := fetch.NewSearcher(backend, servers, mapping, searxOpts)
+		if err != nil {
+			log.Errorf("Error initializing search backend; %s", err.Error())
+			os.Exit(1)
+		}
+		if closer, ok := searcher.(io.Closer); ok {
+			defer closer.Close()
+		}
 
-		f, err := fetch.NewFetcher(server, contentType, languagesAllExt, languagesAllSimple, stream, languages...)
+		// Report per-endpoint statistics at the end of the run
+		if reporter, ok := searcher.(fetch.StatsReporter); ok {
+			defer reportEndpointStats(reporter)
+		}
+
+		f, err := fetch.NewFetcher(searcher, contentType, languagesAllExt, languagesAllSimple, languages...)
 		if err != nil {
 			log.Errorf("Error initializing Fetcher; %s", err.Error())
 			os.Exit(1)
 		}
 
-		for i := 0; i < pages; i++ {
-			res := f.FetchAsync(query, pageno)
-			for {
-				if res.Ready {
-					break
+		f.Concurrency = viper.GetInt("fetch.concurrency")
+
+		// Search parameters; validated against the capabilities advertised by the backend
+		params, err := fetch.NewQueryParams(engines, safeSearch, timeRange)
+		if err != nil {
+			log.Errorf("Error parsing search parameters; %s", err.Error())
+			os.Exit(1)
+		}
+		f.Params = *params
+		if err := f.ValidateParams(); err != nil {
+			log.Errorf("Error validating search parameters; %s", err.Error())
+			os.Exit(1)
+		}
+
+		// Result filtering rules
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			log.Errorf("Error initializing result filters; %s", err.Error())
+			os.Exit(1)
+		}
+		if filter.Len() > 0 {
+			f.Filter = filter
+			defer reportFilterStats(filter)
+		}
+
+		// Persistent cross-run de-dup
+		if c := mustOpenCache(cmd); c != nil {
+			defer c.Close()
+			f.Cache = c
+		}
+
+		// Cancel in-flight queries on shutdown signal or deadline
+		ctx, cancel := signalContext()
+		defer cancel()
+
+		if deadline > 0 {
+			ctx, cancel = context.WithTimeout(ctx, deadline)
+			defer cancel()
+		}
+
+		// Paginate until the target number of unique results is collected
+		if target > 0 {
+			results, done := f.FetchTarget(ctx, query, fetch.TargetOptions{
+				Target:    target,
+				StartPage: pageno,
+				MaxPages:  maxPages,
+				Patience:  patience,
+			})
+
+			res := &fetch.Result{Query: query, PageNo: pageno, QueryParams: f.Params}
+			for r := range results {
+				if stream {
+					outputSearxResult(r)
+				} else {
+					res.Results = append(res.Results, r)
+					res.ResultNo++
 				}
+			}
+
+			summary := <-done
+			if len(summary.Errors) > 0 {
+				log.Warn("Fetch results contain errors; some or all results may not be present")
+				log.Warn(summary.Errors)
+			}
+			fmt.Fprintln(os.Stderr, summary.String())
 
-				time.Sleep(200 * time.Millisecond)
+			if !stream {
+				outputFetchResult(res)
 			}
+			return
+		}
 
+		for i := 0; i < pages && ctx.Err() == nil; i++ {
+			results, done := f.FetchAsync(ctx, query, pageno)
+
+			// Stream results to stdout as they are received
+			for r := range results {
+				if stream {
+					outputSearxResult(r)
+				}
+			}
+
+			res := <-done
 			if res.HasErrors() {
 				log.Warn("Fetch results contain errors; some or all results may not be present")
 				log.Warn(res.Errors)
@@ -73,14 +256,7 @@ Note that the 'resultno' field is only available when batch downloading i.e. not
 
 			// Output result set if not streaming
 			if !stream {
-				jsonBytes, err := res.ToJSON()
-				if err != nil {
-					log.Errorf("unable to output fetch results to JSON; %s", err.Error())
-					os.Exit(1)
-				}
-
-				// Output to stdout
-				fmt.Fprint(os.Stdout, string(jsonBytes))
+				outputFetchResult(res)
 			}
 
 			pageno++
@@ -88,6 +264,27 @@ Note that the 'resultno' field is only available when batch downloading i.e. not
 	},
 }
 
+// outputSearxResult is a helper function for streaming a single result to stdout
+func outputSearxResult(r fetch.SearxResult) {
+	j, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return
+	}
+	fmt.Fprint(os.Stdout, string(j)+"\n")
+}
+
+// outputFetchResult is a helper function for outputting a result set to stdout
+func outputFetchResult(res *fetch.Result) {
+	jsonBytes, err := res.ToJSON()
+	if err != nil {
+		log.Errorf("unable to output fetch results to JSON; %s", err.Error())
+		os.Exit(1)
+	}
+
+	// Output to stdout
+	fmt.Fprint(os.Stdout, string(jsonBytes))
+}
+
 func init() {
 	rootCmd.AddCommand(fetchCmd)
 
@@ -96,12 +293,112 @@ func init() {
 	fetchCmd.MarkFlagRequired("type")
 
 	// Optional args
-	fetchCmd.Flags().StringP("server", "s", "http://127.0.0.1:8080", "Searx server URL")
+	fetchCmd.Flags().String("backend", fetch.BackendSearx, "Search backend (searx, json, file)")
+	fetchCmd.Flags().StringSliceP("server", "s", []string{"http://127.0.0.1:8080"}, "Search backend addresses (server URLs or filepath)")
+	fetchCmd.Flags().String("json-results", "", "Path to the result array in JSON API responses")
+	fetchCmd.Flags().StringToString("json-fields", map[string]string{}, "Result field to JSON path mappings for the JSON API backend")
+	fetchCmd.Flags().StringToString("json-params", map[string]string{}, "Request parameter names (query, page, lang, category) for the JSON API backend")
 	fetchCmd.Flags().Bool("stream", false, "Stream results as they come in")
 	fetchCmd.Flags().Bool("all-langs-ext", false, "Search all extended languages")
 	fetchCmd.Flags().Bool("all-langs-simple", true, "Search all simplified languages")
 	fetchCmd.Flags().StringSliceP("languages", "l", []string{}, "Languages to search in")
 	fetchCmd.Flags().IntP("pageno", "p", 1, "Page number to search")
 	fetchCmd.Flags().IntP("pages", "n", 1, "Pages to fetch")
+	fetchCmd.Flags().Int("target", 0, "Paginate until this many unique results are collected (overrides --pages)")
+	fetchCmd.Flags().Int("max-pages", 50, "Maximum pages to fetch in --target mode (0 for no cap)")
+	fetchCmd.Flags().Int("patience", 2, "Consecutive pages without new results after which --target mode stops")
+	fetchCmd.Flags().StringSlice("engines", []string{}, "Searx engines to query e.g. 'bing images,flickr' (default all enabled engines)")
+	fetchCmd.Flags().String("safesearch", "", "Safe search level: off (0), moderate (1) or strict (2) (default server setting)")
+	fetchCmd.Flags().String("time-range", "", "Only return results from the last day, week, month or year")
+	addCacheFlags(fetchCmd.Flags())
+
+	// Searx client args; configurable from the config file e.g. 'fetch.rate: 1.5'
+	searxOpts := fetch.DefaultSearxOptions()
+	fetchCmd.Flags().Int("concurrency", fetch.DefaultConcurrency, "Maximum number of in-flight queries")
+	fetchCmd.Flags().Float64("rate", searxOpts.Rate, "Maximum queries per second to each Searx endpoint (0 for unlimited)")
+	fetchCmd.Flags().Int("burst", searxOpts.Burst, "Number of queries allowed to exceed the rate in a burst")
+	fetchCmd.Flags().Int("retries", searxOpts.Retry.MaxRetries, "Maximum retries of failed queries")
+	fetchCmd.Flags().Duration("timeout", searxOpts.Timeout, "Per-query timeout")
+	fetchCmd.Flags().Duration("backoff", searxOpts.Retry.BaseDelay, "Initial retry backoff delay")
+	fetchCmd.Flags().Duration("backoff-max", searxOpts.Retry.MaxDelay, "Maximum retry backoff delay")
+	fetchCmd.Flags().Int("eject-after", searxOpts.FailureThreshold, "Consecutive failures after which a Searx endpoint is ejected (0 to disable)")
+	fetchCmd.Flags().Duration("eject-duration", searxOpts.EjectDuration, "Duration an unhealthy Searx endpoint is ejected for")
+	fetchCmd.Flags().Duration("health-interval", searxOpts.HealthInterval, "Interval between Searx endpoint health checks (0 to disable)")
+	for _, name := range []string{
+		"concurrency", "rate", "burst", "retries", "timeout", "backoff", "backoff-max",
+		"eject-after", "eject-duration", "health-interval",
+	} {
+		viper.BindPFlag("fetch."+name, fetchCmd.Flags().Lookup(name))
+	}
+	fetchCmd.Flags().Duration("deadline", 0, "Maximum duration of the fetch e.g. '5m' (0 for no deadline)")
+
+	// Result filter args
+	fetchCmd.Flags().String("filters", "", "YAML file of result filter rules")
+	fetchCmd.Flags().StringSlice("allow-engine", []string{}, "Only keep results from these engines")
+	fetchCmd.Flags().StringSlice("deny-engine", []string{}, "Remove results from these engines")
+	fetchCmd.Flags().StringSlice("allow-domain", []string{}, "Only keep results from these domains e.g. '*.wikimedia.org'")
+	fetchCmd.Flags().StringSlice("deny-domain", []string{}, "Remove results from these domains e.g. '*.pinterest.com'")
+	fetchCmd.Flags().StringArray("title-include", []string{}, "Only keep results with a title matching this regular expression")
+	fetchCmd.Flags().StringArray("title-exclude", []string{}, "Remove results with a title matching this regular expression")
+	fetchCmd.Flags().StringSlice("allow-format", []string{}, "Only keep results in these image formats e.g. 'jpeg,png'")
+	fetchCmd.Flags().Int("min-width", 0, "Remove results narrower than this many pixels")
+	fetchCmd.Flags().Int("min-height", 0, "Remove results shorter than this many pixels")
+
+}
+
+// filterFromFlags is a helper function for building the result filter from the '--filters' file and filter flags
+func filterFromFlags(cmd *cobra.Command) (*fetch.Filter, error) {
+	cfg := &fetch.FilterConfig{}
+	if filepath, _ := cmd.Flags().GetString("filters"); filepath != "" {
+		var err error
+		if cfg, err = fetch.LoadFilterConfig(filepath); err != nil {
+			return nil, err
+		}
+	}
+
+	appendFlag := func(dst *[]string, name string) {
+		var values []string
+		if cmd.Flags().Lookup(name).Value.Type() == "stringArray" {
+			values, _ = cmd.Flags().GetStringArray(name)
+		} else {
+			values, _ = cmd.Flags().GetStringSlice(name)
+		}
+		*dst = append(*dst, values...)
+	}
+	appendFlag(&cfg.EngineAllow, "allow-engine")
+	appendFlag(&cfg.EngineDeny, "deny-engine")
+	appendFlag(&cfg.DomainAllow, "allow-domain")
+	appendFlag(&cfg.DomainDeny, "deny-domain")
+	appendFlag(&cfg.TitleInclude, "title-include")
+	appendFlag(&cfg.TitleExclude, "title-exclude")
+	appendFlag(&cfg.FormatAllow, "allow-format")
+
+	if cmd.Flags().Changed("min-width") {
+		cfg.MinWidth, _ = cmd.Flags().GetInt("min-width")
+	}
+	if cmd.Flags().Changed("min-height") {
+		cfg.MinHeight, _ = cmd.Flags().GetInt("min-height")
+	}
+
+	return fetch.NewFilter(cfg)
+}
+
+// reportFilterStats is a helper function for outputting the number of results removed by each filter rule to STDERR
+func reportFilterStats(filter *fetch.Filter) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILTER\tREMOVED")
+	for _, s := range filter.Stats() {
+		fmt.Fprintf(w, "%s\t%d\n", s.Rule, s.Removed)
+	}
+	w.Flush()
+}
 
+// reportEndpointStats is a helper function for outputting per-endpoint query statistics to STDERR
+func reportEndpointStats(reporter fetch.StatsReporter) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tHEALTHY\tREQUESTS\tFAILURES\tEJECTIONS\tAVG LATENCY")
+	for _, s := range reporter.Stats() {
+		fmt.Fprintf(w, "%s\t%t\t%d\t%d\t%d\t%s\n", s.Addr, s.Healthy, s.Requests, s.Failures, s.Ejections, s.AvgLatency.Round(time.Millisecond))
+	}
+	w.Flush()
 }
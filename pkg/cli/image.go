@@ -5,14 +5,14 @@
  * File Created: Sunday, 5th April 2020 7:58:49 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Wednesday, 5th January 2022 12:06:07 pm
+ * Last Modified: Saturday, 17th October 2026 7:05:55 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package cli
 
 import (
-	"bufio"
 	"fmt"
+	"image/jpeg"
 	"io/ioutil"
 	"os"
 	"os/signal"
@@ -34,7 +34,45 @@ var (
 var imageCmd = &cobra.Command{
 	Use:   "image <image path>",
 	Short: "Formats an image",
-	Long:  `Formats the content type or size of image.`,
+	Long: `Formats the content type or size of image.
+
+JPEG, PNG, GIF, BMP, TIFF and WebP images are read. '--format' converts images to JPEG, PNG, GIF, BMP
+or TIFF, given as a MIME type e.g. 'image/jpeg' or a name e.g. 'jpg'; WebP can only be read, so processed
+WebP images are written as PNG with a '.png' extension unless converted with '--format'.
+
+Converted and resized images are encoded with '--jpeg-quality' and '--png-compression'. Transparent areas
+are flattened onto the '--background' color e.g. '#ffffff' when converting to a format without transparency
+(JPEG, GIF). JPEG chroma subsampling is always 4:2:0 as the encoder does not support other modes.
+
+'--mode' sets how images are resized to '--width' and '--height': 'stretch' (default) scales to exactly
+the size, preserving the aspect ratio if one side is 0; 'fit' scales within the size; 'fill' scales to
+cover the size and crops the overflow, keeping the '--crop' anchor e.g. 'center' or 'top-left', or the
+region with the most detail for 'smart'; 'pad' scales within the size and letterboxes the borders with
+'--pad-color'. '--no-upscale' never enlarges images and '--filter' selects the resampling filter.
+
+'--ops' applies a pipeline of operations in order, decoding and encoding each image once, instead of
+'--format' and the resize flags. Operations are separated by ';', each a name optionally followed by ':'
+and comma separated key=value arguments; a value without a key is the operation's main argument e.g.
+'resize:width=224,height=224,mode=pad;grayscale;format:png'. '--recipe' reads the operations from a
+YAML file instead, each entry an 'op' name alongside its arguments:
+
+  operations:
+    - op: resize
+      width: 224
+      height: 224
+      mode: pad
+    - op: format
+      type: png
+
+Operations: format (type), resize (width, height, mode, crop, pad-color, no-upscale, filter), grayscale,
+flip (direction: horizontal, vertical), rotate (angle in degrees counter-clockwise, background),
+blur (sigma) and sharpen (sigma).
+
+With '--stream', input lines are either plain image paths or JSON records as output by
+'download --output jsonl'. With '--output jsonl', one JSON record is output per input with the
+processed path, size, content type, dimensions and SHA-256 hash, carrying over the source URL of
+input records. Input records of failed or skipped downloads are passed through unprocessed.
+Once stdin is exhausted, the queued images are finished and a processed/failed summary is logged.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		streamInput, _ := cmd.Flags().GetBool("stream")
@@ -44,6 +82,26 @@ var imageCmd = &cobra.Command{
 		width, _ := cmd.Flags().GetInt("width")
 		format, _ := cmd.Flags().GetString("format")
 		silent, _ := cmd.Flags().GetBool("silent")
+		output, _ := cmd.Flags().GetString("output")
+		queueSize, _ := cmd.Flags().GetInt("queue-size")
+		taskTimeout, _ := cmd.Flags().GetDuration("task-timeout")
+		ordered, _ := cmd.Flags().GetBool("ordered")
+		jpegQuality, _ := cmd.Flags().GetInt("jpeg-quality")
+		pngCompression, _ := cmd.Flags().GetString("png-compression")
+		background, _ := cmd.Flags().GetString("background")
+		mode, _ := cmd.Flags().GetString("mode")
+		mode = strings.ToLower(mode)
+		crop, _ := cmd.Flags().GetString("crop")
+		padColor, _ := cmd.Flags().GetString("pad-color")
+		noUpscale, _ := cmd.Flags().GetBool("no-upscale")
+		filter, _ := cmd.Flags().GetString("filter")
+		opsSpec, _ := cmd.Flags().GetString("ops")
+		recipe, _ := cmd.Flags().GetString("recipe")
+
+		if err := checkOutputMode(output); err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
 
 		var contentType *string
 		if format != "" {
@@ -59,9 +117,21 @@ var imageCmd = &cobra.Command{
 		}
 
 		// Configure size conversion parameters
+		var err error
 		var sizeParams *image.SizeConversionParams
-		if height != 0 || width != 0 {
-			sizeParams = &image.SizeConversionParams{Height: height, Width: width}
+		if height != 0 || width != 0 || image.ResizeMode(mode) != image.ResizeStretch {
+			sizeParams = &image.SizeConversionParams{
+				Height:    height,
+				Width:     width,
+				Mode:      image.ResizeMode(mode),
+				Crop:      crop,
+				NoUpscale: noUpscale,
+				Filter:    filter,
+			}
+			if sizeParams.PadColor, err = image.ParseColor(padColor); err != nil {
+				log.Error(err.Error())
+				os.Exit(1)
+			}
 		}
 
 		// Initialize the Imager
@@ -75,6 +145,47 @@ var imageCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		// Configure the operation pipeline
+		if opsSpec != "" || recipe != "" {
+			if opsSpec != "" && recipe != "" {
+				log.Error("Only one of --ops and --recipe may be set")
+				os.Exit(1)
+			}
+			if contentType != nil || sizeParams != nil {
+				log.Error("--ops and --recipe can't be combined with --format or resize flags; add format and resize operations instead")
+				os.Exit(1)
+			}
+
+			if opsSpec != "" {
+				imgr.Operations, err = image.ParseOperations(opsSpec)
+			} else {
+				imgr.Operations, err = image.LoadRecipe(recipe)
+			}
+			if err != nil {
+				log.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		// Configure encoder settings
+		if jpegQuality < 1 || jpegQuality > 100 {
+			log.Errorf("Invalid JPEG quality %d; must be between 1 and 100", jpegQuality)
+			os.Exit(1)
+		}
+		imgr.Encoding = &image.EncodeOptions{JPEGQuality: jpegQuality}
+		if imgr.Encoding.PNGCompression, err = image.ParsePNGCompression(pngCompression); err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+		if imgr.Encoding.Background, err = image.ParseColor(background); err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+
+		imgr.QueueSize = queueSize
+		imgr.TaskTimeout = taskTimeout
+		imgr.Ordered = ordered
+
 		// Kick off Imager worker routines
 		err = imgr.Start()
 		if err != nil {
@@ -83,20 +194,55 @@ var imageCmd = &cobra.Command{
 		}
 		defer imgr.Stop()
 
+		// Input records which are not processed e.g. failed downloads
+		passthrough := make(chan *outputRecord, 100)
+		summaries := make(chan *image.Summary, 1)
+
 		if streamInput {
-			// Streaming input
+			// Streaming input; drain the Imager once stdin is exhausted
 			go func() {
-				scanner := bufio.NewScanner(os.Stdin)
+				defer func() {
+					close(passthrough)
+					summaries <- imgr.Close()
+				}()
+
+				scanner := newInputScanner()
 				for scanner.Scan() {
-					inpath := scanner.Text()
+					if strings.TrimSpace(scanner.Text()) == "" {
+						continue
+					}
+
+					inpath, rec, err := parseImageInput(scanner.Text())
+					if err != nil {
+						log.Infof("error processing input input=%s, error=%v", scanner.Text(), err)
+						passthrough <- &outputRecord{Error: err.Error()}
+						continue
+					}
 
-					imgBytes, err := ioutil.ReadFile(strings.TrimSpace(inpath))
+					if rec != nil && (rec.Error != "" || rec.Skipped || rec.Path == "") {
+						passthrough <- rec
+						continue
+					}
+
+					imgBytes, err := ioutil.ReadFile(inpath)
 					if err != nil {
 						log.Infof("error processing input input=%s, error=%v", inpath, err)
+						if rec == nil {
+							rec = &outputRecord{Path: inpath}
+						}
+						rec.Error = err.Error()
+						passthrough <- rec
 						continue
 					}
 
-					imgr.InChan <- &image.Image{ImageBytes: imgBytes, OriginalFilepath: inpath}
+					img := &image.Image{ImageBytes: imgBytes, OriginalFilepath: inpath}
+					if rec != nil {
+						img.Tag = rec
+					}
+					imgr.InChan <- img
+				}
+				if err := scanner.Err(); err != nil {
+					log.Errorf("error reading input: %s", err.Error())
 				}
 			}()
 		} else {
@@ -109,6 +255,10 @@ var imageCmd = &cobra.Command{
 			}
 
 			imgr.InChan <- &image.Image{ImageBytes: imgBytes, OriginalFilepath: inpath}
+			close(passthrough)
+			go func() {
+				summaries <- imgr.Close()
+			}()
 		}
 
 		// Wait for all input to be processed
@@ -116,15 +266,22 @@ var imageCmd = &cobra.Command{
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 		received := 0
+		passed := 0
+		out := imgr.OutChan
 
-		for {
+		for out != nil || passthrough != nil {
 			select {
-			case f := <-imgr.OutChan:
+			case f, ok := <-out:
+				if !ok {
+					out = nil
+					continue
+				}
 				received++
 
 				// Verbose output
 				if received%10 == 0 {
-					log.Infof("processed: %d", received)
+					stats := imgr.Stats()
+					log.Infof("processed: %d (queued: %d, in flight: %d)", received, stats.Queued, stats.InFlight)
 				}
 
 				// Check if unable to process
@@ -135,7 +292,7 @@ var imageCmd = &cobra.Command{
 						log.Errorf("error writing new image '%s'", path.Base(f.ProcessedFilepath))
 					}
 
-					if !silent {
+					if !silent && output == outputPath {
 						fmt.Fprint(os.Stdout, f.ProcessedFilepath+"\n")
 					}
 
@@ -144,9 +301,19 @@ var imageCmd = &cobra.Command{
 					}
 				}
 
-				// If not streaming, break
-				if !streamInput {
-					return
+				if !silent && output == outputJSONL {
+					writeRecord(newImageRecord(f))
+				}
+
+			case rec, ok := <-passthrough:
+				if !ok {
+					passthrough = nil
+					continue
+				}
+				passed++
+
+				if !silent && output == outputJSONL {
+					writeRecord(rec)
 				}
 
 			case <-sigs:
@@ -154,6 +321,9 @@ var imageCmd = &cobra.Command{
 				return
 			}
 		}
+
+		summary := <-summaries
+		log.Infof("finished: %s, passed through: %d", summary.String(), passed)
 	},
 }
 
@@ -162,10 +332,24 @@ func init() {
 
 	// Optional args
 	imageCmd.Flags().IntP("workers", "w", 4, "Number of workers to process images")
-	imageCmd.Flags().StringP("format", "f", "", "Format to standardize images")
+	imageCmd.Flags().StringP("format", "f", "", "Format to standardize images (jpeg, png, gif, bmp, tiff)")
 	imageCmd.Flags().IntP("width", "x", 0, "Width to standardize image")
 	imageCmd.Flags().IntP("height", "y", 0, "Height to standardize image")
 	imageCmd.Flags().Bool("stream", false, "Streaming input")
 	imageCmd.Flags().Bool("replace", false, "Replace original image")
 	imageCmd.Flags().Bool("silent", false, "Do not output downloaded filepaths")
+	imageCmd.Flags().String("output", outputPath, "Output format (path, jsonl)")
+	imageCmd.Flags().Int("jpeg-quality", jpeg.DefaultQuality, "JPEG quality from 1 to 100")
+	imageCmd.Flags().String("png-compression", "default", "PNG compression level (default, none, fast, best)")
+	imageCmd.Flags().String("background", "white", "Color transparent areas are flattened onto for formats without transparency e.g. '#ffffff'")
+	imageCmd.Flags().String("mode", string(image.ResizeStretch), "Resize mode (stretch, fit, fill, pad)")
+	imageCmd.Flags().String("crop", "center", "Region kept by the fill resize mode (center, top-left, top, top-right, left, right, bottom-left, bottom, bottom-right, smart)")
+	imageCmd.Flags().String("pad-color", "black", "Color of the borders added by the pad resize mode e.g. '#000000'")
+	imageCmd.Flags().Bool("no-upscale", false, "Never enlarge images smaller than the resize width and height")
+	imageCmd.Flags().String("filter", "lanczos", "Resampling filter (nearest, box, linear, catmullrom, mitchell, lanczos)")
+	imageCmd.Flags().String("ops", "", "Pipeline of operations e.g. 'resize:width=224,height=224,mode=pad;format:png'")
+	imageCmd.Flags().String("recipe", "", "YAML file declaring a pipeline of operations")
+	imageCmd.Flags().Int("queue-size", 100, "Number of images buffered ahead of the workers")
+	imageCmd.Flags().Duration("task-timeout", 0, "Maximum duration of a single image (0 for unlimited); only checked between the decode, operation and encode steps, so a slow step is not cut short")
+	imageCmd.Flags().Bool("ordered", false, "Output processed images in the order they were input")
 }
@@ -0,0 +1,155 @@
+/*
+ * File: output.go
+ * Project: cli
+ * File Created: Saturday, 17th October 2026 6:04:38 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:54:36 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/download"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
+)
+
+const (
+	// outputPath outputs one filepath per line
+	outputPath = "path"
+	// outputJSONL outputs one JSON encoded outputRecord per line
+	outputJSONL = "jsonl"
+)
+
+// maxInputLine is the maximum length of a line of STDIN input. data URIs are passed to 'download' inline,
+// so lines may be far longer than the default limit of bufio.Scanner.
+const maxInputLine = 64 * 1024 * 1024
+
+// outputRecord is the JSON Lines record output by the download and image commands.
+// The image command accepts these records as input, so metadata flows through the pipeline.
+// data URIs are shortened (see download.DisplayURL) rather than output verbatim.
+type outputRecord struct {
+	SourceURL    string `json:"source_url,omitempty"`
+	SanitizedURL string `json:"sanitized_url,omitempty"`
+	InputPath    string `json:"input_path,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Size         int    `json:"size"`
+	ContentType  string `json:"content_type,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// newInputScanner is a helper function for scanning the lines of STDIN input
+func newInputScanner() *bufio.Scanner {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), maxInputLine)
+	return scanner
+}
+
+// checkOutputMode is a helper function for validating the '--output' option
+func checkOutputMode(mode string) error {
+	if mode != outputPath && mode != outputJSONL {
+		return fmt.Errorf("unrecognized output '%s'; must be one of %s, %s", mode, outputPath, outputJSONL)
+	}
+	return nil
+}
+
+// writeRecord is a helper function for outputting a record as a single line to stdout
+func writeRecord(r *outputRecord) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	fmt.Fprint(os.Stdout, string(b)+"\n")
+}
+
+// newFileRecord is a helper function for creating the output record of a downloaded File
+func newFileRecord(f *download.File) *outputRecord {
+	r := &outputRecord{
+		SourceURL:    download.DisplayURL(f.RawURL),
+		SanitizedURL: download.DisplayURL(f.SanitizedURL),
+		Size:         f.Size,
+		ContentType:  f.ContentType,
+		Hash:         f.Hash,
+		Skipped:      f.Cached,
+	}
+
+	if f.Name != "" {
+		r.Path = path.Join(f.Location, f.Name)
+	}
+
+	if f.Error != nil {
+		r.Error = f.Error.Error()
+		return r
+	}
+
+	// Dimensions of stored images, unless recorded by the downloader's validation
+	r.Width, r.Height = f.Width, f.Height
+	if r.Width == 0 && r.Path != "" && strings.HasPrefix(f.ContentType, "image/") {
+		if b, err := ioutil.ReadFile(r.Path); err == nil {
+			if stats, err := image.GetStats(b); err == nil {
+				r.Width, r.Height = stats.Width, stats.Height
+			}
+		}
+	}
+
+	return r
+}
+
+// newImageRecord is a helper function for creating the output record of a processed Image.
+// Metadata of the input record, if any, is carried over.
+func newImageRecord(img *image.Image) *outputRecord {
+	r := &outputRecord{}
+	if in, ok := img.Tag.(*outputRecord); ok {
+		*r = *in
+	}
+
+	r.InputPath = img.OriginalFilepath
+	r.Path = img.ProcessedFilepath
+
+	if img.Err != nil {
+		r.Error = img.Err.Error()
+		return r
+	}
+
+	sum := sha256.Sum256(img.ImageBytes)
+	r.Size = len(img.ImageBytes)
+	r.Hash = hex.EncodeToString(sum[:])
+	r.ContentType = image.DetectContentType(img.ImageBytes)
+	r.Width, r.Height = 0, 0
+	if stats, err := image.GetStats(img.ImageBytes); err == nil {
+		r.Width, r.Height = stats.Width, stats.Height
+	}
+
+	return r
+}
+
+// parseImageInput is a helper function for parsing a line of image command input.
+// Lines are either a plain filepath or a JSON encoded outputRecord e.g. from 'download --output jsonl'.
+// Returns a nil record for plain filepaths.
+func parseImageInput(line string) (string, *outputRecord, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return line, nil, nil
+	}
+
+	var r outputRecord
+	if err := json.Unmarshal([]byte(line), &r); err != nil {
+		return "", nil, fmt.Errorf("unable to parse input record; %s", err.Error())
+	}
+
+	return r.Path, &r, nil
+}
@@ -5,14 +5,17 @@
  * File Created: Sunday, 22nd March 2020 1:38:34 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Sunday, 31st May 2020 3:56:26 pm
+ * Last Modified: Saturday, 17th October 2026 5:34:44 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -95,3 +98,24 @@ func initConfig() {
 		log.SetLevel(log.ErrorLevel)
 	}
 }
+
+// signalContext returns a context that is cancelled when a shutdown signal is received
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(sigs)
+
+		select {
+		case <-sigs:
+			log.Info("Received shutdown signal, exiting")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,377 @@
+// Package download provides file download utilities
+/*
+ * File: client.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:59:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:04:02 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
+)
+
+// ClientOptions is a struct for configuring the Downloader's HTTP client
+type ClientOptions struct {
+	// ConnectTimeout bounds establishing a connection, including the TLS handshake. 0 disables the timeout.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds waiting for the response headers once the request is sent. 0 disables the timeout.
+	ReadTimeout time.Duration
+	// Timeout bounds a whole request, including reading the body. 0 disables the timeout.
+	Timeout time.Duration
+	// Retry is the retry policy for transient failures i.e. network errors, 429 and 5xx responses
+	Retry throttle.Policy
+
+	// HostConcurrency is the maximum number of in-flight requests to a single host. 0 disables the limit.
+	HostConcurrency int
+	// HostDelay is the minimum delay between the start of requests to a single host
+	HostDelay time.Duration
+
+	// BreakerThreshold is the number of consecutive transient failures after which requests to a host
+	// are rejected without being sent. 0 disables the circuit breaker.
+	BreakerThreshold int
+	// BreakerCooldown is the duration requests to a host are rejected for once its circuit breaker opens.
+	// A single failure after the cooldown re-opens the breaker.
+	BreakerCooldown time.Duration
+
+	// UserAgent is sent with every request if not empty
+	UserAgent string
+
+	// Guard refuses connections to non-public addresses. nil disables the protection.
+	// Note a proxy configured by the environment must then be trusted by the Guard.
+	Guard *Guard
+}
+
+// DefaultClientOptions returns the default Downloader HTTP client options.
+// Connections to non-public addresses are refused.
+func DefaultClientOptions() *ClientOptions {
+	return &ClientOptions{
+		Guard:          &Guard{},
+		ConnectTimeout: 10 * time.Second,
+		ReadTimeout:    30 * time.Second,
+		Timeout:        5 * time.Minute,
+		Retry: throttle.Policy{
+			MaxRetries: 3,
+			BaseDelay:  500 * time.Millisecond,
+			MaxDelay:   30 * time.Second,
+		},
+		HostConcurrency:  4,
+		HostDelay:        0,
+		BreakerThreshold: 5,
+		BreakerCooldown:  2 * time.Minute,
+	}
+}
+
+// StatusError is an error for representing an unsuccessful HTTP response status
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// CircuitOpenError is an error returned for requests to a host whose circuit breaker is open
+type CircuitOpenError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for host [%s] until %s after repeated failures", e.Host, e.Until.Format(time.RFC3339))
+}
+
+// Client is an HTTP client shared by the Downloader workers.
+// Client retries transient failures, limits the concurrency and request rate per host,
+// and stops sending requests to hosts which repeatedly fail. Client is safe for concurrent use.
+type Client struct {
+	opts   *ClientOptions
+	client *http.Client
+	hosts  map[string]*hostState
+	sync.Mutex
+}
+
+// hostState is a struct for tracking the politeness and circuit breaker state of a single host
+type hostState struct {
+	// sem bounds the in-flight requests; nil if unlimited
+	sem chan struct{}
+	// next is the earliest time the next request may start
+	next time.Time
+	// failures is the number of consecutive transient failures
+	failures int
+	// openUntil is the time the circuit breaker closes
+	openUntil time.Time
+	sync.Mutex
+}
+
+// NewClient is a function for initializing a Client. If opts is nil, the DefaultClientOptions are used.
+func NewClient(opts *ClientOptions) *Client {
+	if opts == nil {
+		opts = DefaultClientOptions()
+	}
+
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	if opts.Guard != nil {
+		dial = opts.Guard.dialContext(dialer)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dial,
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.ReadTimeout,
+		MaxIdleConnsPerHost:   opts.HostConcurrency,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &Client{
+		opts:   opts,
+		client: &http.Client{Transport: transport, Timeout: opts.Timeout},
+		hosts:  map[string]*hostState{},
+	}
+}
+
+// Get issues a GET request for urlStr, retrying transient failures according to the retry policy.
+// Only successful (2xx) responses are returned; other responses are returned as a *StatusError.
+// The caller must close the response body, which releases the host's concurrency slot.
+func (c *Client) Get(ctx context.Context, urlStr string) (*http.Response, error) {
+	return c.request(ctx, "GET", urlStr)
+}
+
+// Head issues a HEAD request for urlStr with the same retries, limits and circuit breaking as Get.
+// The caller must close the response body.
+func (c *Client) Head(ctx context.Context, urlStr string) (*http.Response, error) {
+	return c.request(ctx, "HEAD", urlStr)
+}
+
+// request is a helper function for issuing a request, retrying transient failures
+func (c *Client) request(ctx context.Context, method, urlStr string) (*http.Response, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	host := c.host(u.Host)
+
+	for attempt := 0; ; attempt++ {
+		if err := host.allow(u.Host); err != nil {
+			return nil, err
+		}
+
+		release, err := host.acquire(ctx, c.opts.HostDelay)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.do(ctx, method, urlStr)
+		if err == nil {
+			host.success()
+			resp.Body = &releaseCloser{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		release()
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		retryable, retryAfter := isRetryable(err)
+		if !retryable {
+			return nil, err
+		}
+
+		// Be polite to hosts asking us to back off
+		host.backoff(retryAfter)
+		if host.failure(c.opts.BreakerThreshold, c.opts.BreakerCooldown) {
+			log.Warnf("circuit breaker opened for host [%s] for %s after %d consecutive failures", u.Host, c.opts.BreakerCooldown, c.opts.BreakerThreshold)
+		}
+
+		if attempt >= c.opts.Retry.MaxRetries {
+			return nil, err
+		}
+
+		delay := c.opts.Retry.Delay(attempt, retryAfter)
+		log.Debugf("retrying download: url=%s, attempt=%d, delay=%s, error=%s", urlStr, attempt+1, delay, err)
+
+		if err := throttle.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// do is a helper function for issuing a single request
+func (c *Client) do(ctx context.Context, method, urlStr string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Drain a little of the body so the connection can be reused
+		io.CopyN(ioutil.Discard, resp.Body, 4096)
+		resp.Body.Close()
+		return nil, &StatusError{Code: resp.StatusCode, RetryAfter: throttle.RetryAfter(resp)}
+	}
+
+	return resp, nil
+}
+
+// host is a helper function for retrieving the state of a host, creating it if needed
+func (c *Client) host(name string) *hostState {
+	c.Lock()
+	defer c.Unlock()
+
+	h, ok := c.hosts[name]
+	if !ok {
+		h = &hostState{}
+		if c.opts.HostConcurrency > 0 {
+			h.sem = make(chan struct{}, c.opts.HostConcurrency)
+		}
+		c.hosts[name] = h
+	}
+
+	return h
+}
+
+// allow returns a *CircuitOpenError if the host's circuit breaker is open
+func (h *hostState) allow(name string) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if time.Now().Before(h.openUntil) {
+		return &CircuitOpenError{Host: name, Until: h.openUntil}
+	}
+	return nil
+}
+
+// acquire waits for a concurrency slot and the host's minimum delay.
+// Returns a function releasing the slot.
+func (h *hostState) acquire(ctx context.Context, delay time.Duration) (func(), error) {
+	release := func() {}
+	if h.sem != nil {
+		select {
+		case h.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-h.sem }) }
+	}
+
+	// Reserve the next start time
+	h.Lock()
+	now := time.Now()
+	start := now
+	if h.next.After(start) {
+		start = h.next
+	}
+	if next := start.Add(delay); next.After(h.next) {
+		h.next = next
+	}
+	h.Unlock()
+
+	if err := throttle.Sleep(ctx, start.Sub(now)); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// backoff pushes back the next request start time to honor a server requested delay
+func (h *hostState) backoff(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	if next := time.Now().Add(d); next.After(h.next) {
+		h.next = next
+	}
+}
+
+// success resets the host's consecutive failures
+func (h *hostState) success() {
+	h.Lock()
+	h.failures = 0
+	h.Unlock()
+}
+
+// failure records a transient failure, opening the circuit breaker once the threshold is reached.
+// Returns true if the breaker was opened.
+func (h *hostState) failure(threshold int, cooldown time.Duration) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	h.failures++
+	if threshold <= 0 || h.failures < threshold {
+		return false
+	}
+
+	h.openUntil = time.Now().Add(cooldown)
+	return true
+}
+
+// releaseCloser is a ReadCloser releasing a host's concurrency slot once closed
+type releaseCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
+
+// isRetryable is a helper function for checking if a request error is transient.
+// Returns the server requested delay, if any.
+func isRetryable(err error) (bool, time.Duration) {
+	if se, ok := err.(*StatusError); ok {
+		return throttle.IsRetryableStatus(se.Code), se.RetryAfter
+	}
+
+	// Refused addresses and unknown hosts will fail again
+	var blocked *BlockedAddressError
+	if errors.As(err, &blocked) {
+		return false, 0
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, 0
+	}
+
+	// Network errors including timeouts
+	if ue, ok := err.(*url.Error); ok {
+		err = ue.Err
+	}
+	_, ok := err.(net.Error)
+	return ok, 0
+}
@@ -0,0 +1,179 @@
+/*
+ * File: client_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:59:59 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:59:59 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
+)
+
+func newTestClientOptions() *ClientOptions {
+	return &ClientOptions{
+		Timeout: 5 * time.Second,
+		Retry:   throttle.Policy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := map[string]struct {
+		statuses []int
+		calls    int32
+		status   int
+	}{
+		"Success":         {[]int{200}, 1, 0},
+		"Retry 429":       {[]int{429, 200}, 2, 0},
+		"Retry 5xx":       {[]int{503, 502, 200}, 3, 0},
+		"Retries Exhaust": {[]int{500, 500, 500, 200}, 3, 500},
+		"Not Retryable":   {[]int{404, 200}, 1, 404},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			i := atomic.AddInt32(&calls, 1) - 1
+			w.WriteHeader(test.statuses[i])
+			w.Write([]byte("body"))
+		}))
+
+		resp, err := NewClient(newTestClientOptions()).Get(context.Background(), server.URL)
+
+		assert.Equal(t, test.calls, atomic.LoadInt32(&calls))
+		if test.status != 0 {
+			assert.Error(t, err)
+			if se, ok := err.(*StatusError); assert.True(t, ok) {
+				assert.Equal(t, test.status, se.Code)
+			}
+		} else if assert.NoError(t, err) {
+			b, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, "body", string(b))
+		}
+
+		server.Close()
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := NewClient(newTestClientOptions()).Get(context.Background(), server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.True(t, time.Since(start) >= time.Second, "retry should wait for the Retry-After delay")
+}
+
+func TestClientHostLimits(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	var starts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer server.Close()
+
+	opts := newTestClientOptions()
+	opts.HostConcurrency = 2
+	opts.HostDelay = 20 * time.Millisecond
+	c := NewClient(opts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Get(context.Background(), server.URL)
+			if assert.NoError(t, err) {
+				ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	// Request starts are spaced by at least the host delay (allowing for timer slack)
+	first, last := starts[0], starts[0]
+	for _, s := range starts {
+		if s.Before(first) {
+			first = s
+		}
+		if s.After(last) {
+			last = s
+		}
+	}
+	assert.True(t, last.Sub(first) >= 5*opts.HostDelay-5*time.Millisecond)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	opts := newTestClientOptions()
+	opts.Retry.MaxRetries = 0
+	opts.BreakerThreshold = 2
+	opts.BreakerCooldown = 50 * time.Millisecond
+	c := NewClient(opts)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(context.Background(), server.URL)
+		assert.IsType(t, &StatusError{}, err)
+	}
+
+	// Open; requests are rejected without being sent
+	_, err := c.Get(context.Background(), server.URL)
+	assert.IsType(t, &CircuitOpenError{}, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// After the cooldown a trial request is sent; its failure re-opens the breaker
+	time.Sleep(opts.BreakerCooldown)
+	_, err = c.Get(context.Background(), server.URL)
+	assert.IsType(t, &StatusError{}, err)
+	_, err = c.Get(context.Background(), server.URL)
+	assert.IsType(t, &CircuitOpenError{}, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
@@ -5,19 +5,25 @@
  * File Created: Sunday, 29th March 2020 5:00:08 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Thursday, 28th May 2020 8:54:34 pm
+ * Last Modified: Saturday, 17th October 2026 6:54:36 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package download
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	guuid "github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/cache"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/worker"
 )
 
 // Downloader is a struct for holding a downloader's context variables
@@ -26,14 +32,69 @@ type Downloader struct {
 	NoStore         bool
 	Base64Encoded   bool
 
+	// Cache of previously downloaded URLs. URLs found in the cache are skipped.
+	Cache cache.Cache
+
+	// Namer names the downloaded files; defaults to the sanitized URL basename
+	Namer Namer
+	// ShardDepth is the number of levels of subdirectories, taken from the content hash,
+	// files are spread across e.g. a depth of 2 stores 'image.jpg' as 'ab/cd/image.jpg'
+	ShardDepth int
+	// Query is the search query the URLs were fetched with; available to template naming
+	Query string
+
+	// Client is the HTTP client shared by the workers
+	Client *Client
+	// Limits are the safety limits applied to each download. Optional.
+	Limits *Limits
+
+	// ValidateImages decodes downloaded images before they are accepted, rejecting corrupt, truncated
+	// and zero-pixel images and images of more than MaxPixels pixels. Other content types are not checked.
+	ValidateImages bool
+	// MaxPixels is the maximum number of pixels of a valid image (0 for no limit)
+	MaxPixels int64
+
+	// Manifest records the outcome of every processed URL. Optional.
+	Manifest *Manifest
+	// Resume skips URLs the Manifest records as completed whose files still exist
+	Resume bool
+
 	// Concurrency of this Downloader
 	Concurrency int
+	// QueueSize is the number of URLs and files buffered ahead of and behind the workers
+	QueueSize int
+	// TaskTimeout is the maximum duration of a single URL including retries (0 for unlimited)
+	TaskTimeout time.Duration
+	// Ordered outputs files in the order their URLs were received
+	Ordered bool
+
+	// index is the number of URLs received
+	index int64
+	// Summary counters
+	processed, failed, skipped int64
 
 	// Sync vars
-	InChan   chan string
-	OutChan  chan *File
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	InChan    chan string
+	OutChan   chan *File
+	pool      *worker.Pool
+	stopChan  chan struct{}
+	finished  chan struct{}
+	closeOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// Summary is a struct for representing the outcome of a Downloader's run
+type Summary struct {
+	// Processed is the number of URLs output on the OutChan
+	Processed int `json:"processed"`
+	// Failed is the number of processed URLs with an error
+	Failed int `json:"failed"`
+	// Skipped is the number of processed URLs skipped as previously downloaded
+	Skipped int `json:"skipped"`
+}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("processed: %d, downloaded: %d, skipped: %d, failed: %d", s.Processed, s.Processed-s.Failed-s.Skipped, s.Skipped, s.Failed)
 }
 
 // NewDownloader is a function for initializing a new Downloader
@@ -46,61 +107,239 @@ func NewDownloader(concurrency int, dst string, noStore, b64Encoded bool) (*Down
 
 	return &Downloader{
 		Concurrency:     concurrency,
+		QueueSize:       100,
 		DestinationPath: dst,
 		NoStore:         noStore,
 		Base64Encoded:   b64Encoded,
-		InChan:          make(chan string, 100),
-		OutChan:         make(chan *File, 100),
-		stopChan:        make(chan struct{}, 1),
+		Namer:           BasenameNamer{},
+		Client:          NewClient(nil),
+		MaxPixels:       image.DefaultMaxPixels,
+		InChan:          make(chan string),
+		OutChan:         make(chan *File),
+		stopChan:        make(chan struct{}),
+		finished:        make(chan struct{}),
 	}, nil
 }
 
 // Start kicks off the Downloader worker routines
 func (d *Downloader) Start() error {
-	// Start a fixed number of goroutines to read and digest urls.
-	d.wg.Add(d.Concurrency)
-	for i := 0; i < d.Concurrency; i++ {
-		go func() {
-			d.work()
-			d.wg.Done()
-		}()
+	if d.Client == nil {
+		d.Client = NewClient(nil)
 	}
 
+	d.pool = worker.NewPool(context.Background(), d.work, worker.Options{
+		Workers:    d.Concurrency,
+		QueueSize:  d.QueueSize,
+		OutputSize: d.QueueSize,
+		Timeout:    d.TaskTimeout,
+		Ordered:    d.Ordered,
+	})
+	d.pool.Start()
+
+	go d.feed()
+	go d.forward()
+
 	return nil
 }
 
-// Stop the Downloader worker routines and wait for graceful exit
+// Stop aborts the Downloader worker routines and waits for them to exit.
+// In-flight downloads are cancelled and queued URLs are dropped; use Close to finish queued work.
+// The OutChan is closed once the workers exit.
 func (d *Downloader) Stop() {
-	close(d.stopChan)
-	d.wg.Wait()
+	d.stopOnce.Do(func() {
+		close(d.stopChan)
+	})
+	if d.pool == nil {
+		return
+	}
+
+	d.pool.Stop()
+	<-d.finished
 }
 
-func (d *Downloader) work() {
-	id := guuid.New()
-	log.Debugf("Starting Downloader worker routine [%s]\n", id.String())
-	defer log.Debugf("Exiting Downloader worker routine [%s]\n", id.String())
+// Close stops accepting input, waits for the queued URLs to be processed and closes the OutChan.
+// Returns a summary of the run. Nothing may be sent on the InChan once Close is called.
+//
+// Close blocks until the processed files are received from the OutChan, so it must be called
+// from a different goroutine than the one receiving them, e.g. the one sending the input.
+func (d *Downloader) Close() *Summary {
+	d.closeOnce.Do(func() {
+		close(d.InChan)
+	})
+	if d.pool != nil {
+		<-d.finished
+	}
 
+	return &Summary{
+		Processed: int(atomic.LoadInt64(&d.processed)),
+		Failed:    int(atomic.LoadInt64(&d.failed)),
+		Skipped:   int(atomic.LoadInt64(&d.skipped)),
+	}
+}
+
+// Stats returns a snapshot of the Downloader's worker counters
+func (d *Downloader) Stats() worker.Stats {
+	if d.pool == nil {
+		return worker.Stats{}
+	}
+	return d.pool.Stats()
+}
+
+// feed is a helper function for submitting the URLs received on the InChan to the worker pool.
+// The pool is closed once the InChan is closed.
+func (d *Downloader) feed() {
 	for {
 		select {
-		case urlStr := <-d.InChan:
-			if d.Base64Encoded {
-				u, err := base64.StdEncoding.DecodeString(urlStr)
-				if err != nil {
-					log.Warnf("unable to decode b64 encoded url [%s]", urlStr)
-					continue
-				}
-				urlStr = string(u)
+		case urlStr, ok := <-d.InChan:
+			if !ok {
+				d.pool.Close()
+				return
 			}
-
-			f := newFile(urlStr, d.DestinationPath)
-			if f.Error == nil {
-				f.get()
+			if err := d.pool.Submit(urlStr); err != nil {
+				return
 			}
 
-			d.OutChan <- f
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+// forward is a helper function for outputting the files processed by the worker pool on the OutChan.
+// The OutChan is closed once the pool is drained or stopped.
+func (d *Downloader) forward() {
+	defer close(d.finished)
+	defer close(d.OutChan)
 
+	for r := range d.pool.Results() {
+		f, _ := r.Value.(*File)
+		if f == nil {
+			// The worker panicked
+			f = &File{RawURL: r.Item.(string), TimeStamp: time.Now().Unix()}
+		}
+		if r.Err != nil {
+			f.Error = r.Err
+		}
+
+		// Abandon the file rather than block on a consumer which has gone away
+		select {
+		case d.OutChan <- f:
+			d.count(f)
 		case <-d.stopChan:
+			f.discard()
+		}
+	}
+}
+
+// work is a worker.Func for downloading a URL, returning the processed File and its error
+func (d *Downloader) work(ctx context.Context, item interface{}) (interface{}, error) {
+	urlStr := item.(string)
+	if d.Base64Encoded {
+		u, err := base64.StdEncoding.DecodeString(urlStr)
+		if err != nil {
+			log.Warnf("unable to decode b64 encoded url [%s]", urlStr)
+			f := &File{RawURL: urlStr, TimeStamp: time.Now().Unix(), Error: fmt.Errorf("unable to decode b64 encoded url [%s]", urlStr)}
+			return f, f.Error
+		}
+		urlStr = string(u)
+	}
+
+	index := int(atomic.AddInt64(&d.index, 1))
+
+	f := newFile(urlStr, d.DestinationPath)
+	if d.NoStore {
+		f.Location = ""
+	}
+	d.process(ctx, f, index)
+
+	return f, f.Error
+}
+
+// count is a helper function for updating the summary counters with an output file
+func (d *Downloader) count(f *File) {
+	atomic.AddInt64(&d.processed, 1)
+	if f.Error != nil {
+		atomic.AddInt64(&d.failed, 1)
+	} else if f.Cached {
+		atomic.AddInt64(&d.skipped, 1)
+	}
+}
+
+// process is a helper function for retrieving a valid File unless the Downloader is resuming and the
+// Manifest records it as completed. Processed files, including failures, are recorded in the Manifest.
+func (d *Downloader) process(ctx context.Context, f *File, index int) {
+	if d.Manifest == nil {
+		if f.Error == nil {
+			d.getUncached(ctx, f, index)
+		}
+		return
+	}
+
+	if f.Error == nil && d.Resume {
+		if e, ok := d.Manifest.Completed(fileKey(f), f.Location); ok {
+			f.Name, f.Size, f.Hash, f.ContentType = e.Filename, e.Size, e.Hash, e.ContentType
+			f.Width, f.Height = e.Width, e.Height
+			f.Cached = true
+			return
+		}
+	}
+
+	if f.Error == nil {
+		d.getUncached(ctx, f, index)
+		if f.Cached {
+			return
+		}
+	}
+
+	if err := d.Manifest.Record(f); err != nil {
+		log.Warnf("unable to record url [%s] in download manifest; %s", fileKey(f), err.Error())
+	}
+}
+
+// getUncached is a helper function for retrieving and saving a File unless it is found in the Downloader's cache.
+// Successfully retrieved files are recorded in the cache.
+func (d *Downloader) getUncached(ctx context.Context, f *File, index int) {
+	if d.Cache == nil {
+		d.getAndSave(ctx, f, index)
+		return
+	}
+
+	cached, err := d.Cache.Has(cache.Downloaded, fileKey(f))
+	if err != nil {
+		log.Warnf("unable to check download cache for url [%s]; %s", fileKey(f), err.Error())
+	}
+	if cached {
+		f.Cached = true
+		return
+	}
+
+	d.getAndSave(ctx, f, index)
+	if f.Error != nil {
+		return
+	}
+
+	if err := d.Cache.Add(cache.Downloaded, fileKey(f)); err != nil {
+		log.Warnf("unable to record url [%s] in download cache; %s", fileKey(f), err.Error())
+	}
+}
+
+// getAndSave is a helper function for retrieving a File and saving it under the name assigned by the Downloader's Namer
+func (d *Downloader) getAndSave(ctx context.Context, f *File, index int) {
+	f.get(ctx, d.Client, d.Limits)
+	if f.Error != nil {
+		return
+	}
+
+	if d.ValidateImages {
+		if f.validate(d.MaxPixels); f.Error != nil {
 			return
 		}
 	}
+
+	namer := d.Namer
+	if namer == nil {
+		namer = BasenameNamer{}
+	}
+
+	f.save(namer, index, d.Query, d.ShardDepth)
 }
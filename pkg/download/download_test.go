@@ -0,0 +1,173 @@
+/*
+ * File: download_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:19:24 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:27:09 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestDownloaderClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	d, err := NewDownloader(4, "", true, false)
+	assert.NoError(t, err)
+	d.Client = NewClient(newTestClientOptions())
+	d.Start()
+
+	// Queue more URLs than the channels buffer, as a stream of stdin would
+	const count = 250
+	summaries := make(chan *Summary, 1)
+	go func() {
+		for i := 0; i < count; i++ {
+			if i%50 == 0 {
+				d.InChan <- server.URL + "/missing"
+				continue
+			}
+			d.InChan <- fmt.Sprintf("%s/%d.jpg", server.URL, i)
+		}
+		summaries <- d.Close()
+	}()
+
+	received, failed := 0, 0
+	for f := range d.OutChan {
+		received++
+		if f.Error != nil {
+			failed++
+		}
+	}
+
+	summary := <-summaries
+	assert.Equal(t, count, received)
+	assert.Equal(t, &Summary{Processed: count, Failed: 5}, summary)
+	assert.Equal(t, summary.Failed, failed)
+
+	// Close and Stop are safe to call again
+	assert.Equal(t, summary, d.Close())
+	d.Stop()
+}
+
+func TestDownloaderStop(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	d, err := NewDownloader(2, "", true, false)
+	assert.NoError(t, err)
+	d.Client = NewClient(newTestClientOptions())
+	d.Start()
+
+	for i := 0; i < 10; i++ {
+		d.InChan <- fmt.Sprintf("%s/%d.jpg", server.URL, i)
+	}
+
+	// Stop aborts in-flight downloads without a consumer of the OutChan
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	for f := range d.OutChan {
+		assert.Error(t, f.Error)
+	}
+}
+
+func TestDownloaderValidate(t *testing.T) {
+	img, err := test_utils.NewImage("image/png", 40, 20)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(img)
+		case "/truncated.png":
+			w.Write(img[:len(img)-20])
+		case "/bomb.png":
+			w.Write(test_utils.SetPNGDimensions(img, 100000, 100000))
+		case "/page.html":
+			w.Write([]byte("<html><body>Not an image</body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "emld-validate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := map[string]struct {
+		path   string
+		width  int
+		height int
+		err    bool
+	}{
+		"Valid":     {"/image.png", 40, 20, false},
+		"Truncated": {"/truncated.png", 0, 0, true},
+		"Bomb":      {"/bomb.png", 0, 0, true},
+		"Not Image": {"/page.html", 0, 0, false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		d, err := NewDownloader(1, dir, false, false)
+		assert.NoError(t, err)
+		d.Client = NewClient(newTestClientOptions())
+		d.ValidateImages = true
+		d.Start()
+
+		d.InChan <- server.URL + test.path
+		f := <-d.OutChan
+		d.Close()
+
+		if test.err {
+			assert.Error(t, f.Error)
+			assert.Empty(t, f.Name)
+			continue
+		}
+		assert.NoError(t, f.Error)
+		assert.Equal(t, test.width, f.Width)
+		assert.Equal(t, test.height, f.Height)
+	}
+
+	// Rejected images are never written
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, names, 2)
+}
@@ -5,35 +5,58 @@
  * File Created: Sunday, 22nd March 2020 7:25:52 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Tuesday, 7th April 2020 4:49:54 pm
+ * Last Modified: Saturday, 17th October 2026 6:54:36 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package download
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
-	"path"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/image"
 )
 
 // File is a struct for representing the metadata of a saved file
 type File struct {
 	RawURL       string `json:"raw_url"`
 	SanitizedURL string `json:"sanitized_url"`
-	Name         string `json:"name"`
-	TimeStamp    int64  `json:"timestamp"`
-	Location     string `json:"location"`
+	// Name is the filepath relative to Location; assigned once the file is retrieved
+	Name      string `json:"name"`
+	TimeStamp int64  `json:"timestamp"`
+	Location  string `json:"location"`
 
 	Size        int    `json:"size"`
 	ContentType string `json:"content_type"`
-	FileBytes   []byte `json:"-"`
+	Hash        string `json:"hash"`
+	// Width and Height are the dimensions of images checked by File.validate
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// FileBytes holds the file contents if it is not written to the file system i.e. Location is empty
+	FileBytes []byte `json:"-"`
+
+	// Cached indicates the file was skipped as it was previously downloaded
+	Cached bool `json:"cached"`
 
 	Error error `json:"error"`
+
+	// tmpPath is the temporary file the contents are streamed to until saved
+	tmpPath string
+	// key is the manifest and cache key of the File, see fileKey
+	key string
 }
 
 // newFile is a function for initializing a new file with pre-retrieval information
@@ -46,17 +69,6 @@ func newFile(urlStr, dst string) *File {
 	}
 
 	f.SanitizedURL, f.Error = validateURL(urlStr)
-	if f.Error != nil {
-		return f
-	}
-
-	u, err := stripQueryString(f.SanitizedURL)
-	if err != nil {
-		f.Error = err
-		return f
-	}
-
-	f.Name = path.Base(u)
 
 	return f
 }
@@ -65,40 +77,171 @@ func newFile(urlStr, dst string) *File {
 // get will always return a non-nil result as any errors are encapsulated
 // in the File object.
 //
-// If File.Location is an empty string, the file will not be downloaded to the file system.
+// Besides http(s) URLs, the contents of data URIs and, if allowed by the limits, file URLs
+// are retrieved the same way; see File.open.
+//
+// If File.Location is not an empty string, the contents are streamed to a temporary file in
+// File.Location, which File.save renames into place; otherwise they are held in File.FileBytes.
+// The content type is sniffed from the leading bytes and checked against the limits before
+// anything is written, and the size limit is enforced mid-stream. Partial files are removed on error.
 //
 // get will block until an error or the file is received.
-func (f *File) get() {
-	resp, err := http.Get(f.SanitizedURL)
+func (f *File) get(ctx context.Context, client *Client, limits *Limits) {
+	src, length, err := f.open(ctx, client, limits)
 	if err != nil {
-		f.Error = errors.Wrapf(err, "failed issuing a GET response for url [%s]", f.SanitizedURL)
+		f.Error = err
+		return
+	}
+	defer src.Close()
+
+	display := DisplayURL(f.SanitizedURL)
+	if err := limits.checkSize(length); err != nil {
+		f.Error = errors.Wrapf(err, "rejected url [%s]", display)
+		return
+	}
+
+	// Only the first 512 bytes are used to sniff the content type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		f.Error = errors.Wrapf(err, "failed reading contents of url [%s]", display)
 		return
 	}
-	defer resp.Body.Close()
+	head = head[:n]
 
-	fileBytes, err := ioutil.ReadAll(resp.Body)
+	f.ContentType, err = getFileContentType(head)
 	if err != nil {
-		errors.Wrapf(err, "failed reading GET response for url [%s]", f.SanitizedURL)
+		f.Error = errors.Wrapf(err, "failed reading contents of url [%s]", display)
+		return
+	}
+	if err := limits.checkType(f.ContentType); err != nil {
+		f.Error = errors.Wrapf(err, "rejected url [%s]", display)
 		return
 	}
 
+	// Stream the remaining contents, reading at most one byte past the size limit to detect oversized files
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), src)
+	if limits != nil && limits.MaxBytes > 0 {
+		body = io.LimitReader(body, limits.MaxBytes+1)
+	}
+
+	hash := sha256.New()
+	var dst io.Writer
+	var buf *bytes.Buffer
+	var tmp *os.File
+
 	if f.Location != "" {
-		// Create the file
-		name := path.Join(f.Location, f.Name)
-		err := ioutil.WriteFile(name, fileBytes, 0666)
+		if tmp, err = ioutil.TempFile(f.Location, tmpPattern); err != nil {
+			f.Error = errors.Wrapf(err, "failed creating file for url [%s]", display)
+			return
+		}
+		f.tmpPath = tmp.Name()
+		dst = tmp
+
+		// Temporary files are private; saved files keep the permissions of a regular download
+		tmp.Chmod(0644)
+	} else {
+		buf = &bytes.Buffer{}
+		dst = buf
+	}
+
+	size, err := io.Copy(io.MultiWriter(dst, hash), body)
+	if tmp != nil {
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		err = limits.checkSize(size)
+	}
+	if err != nil {
+		f.discard()
+		f.Error = errors.Wrapf(err, "failed reading contents of url [%s]", display)
+		return
+	}
+
+	f.Size = int(size)
+	f.Hash = hex.EncodeToString(hash.Sum(nil))
+	if buf != nil {
+		f.FileBytes = buf.Bytes()
+	}
+}
+
+// validate is a method for checking a retrieved image decodes completely, recording its dimensions.
+// Corrupt, truncated and zero-pixel images and images of more than maxPixels pixels are rejected and
+// discarded. Files which are not images, or whose format has no registered decoder, are not checked.
+func (f *File) validate(maxPixels int64) {
+	if !strings.HasPrefix(f.ContentType, "image/") {
+		return
+	}
+
+	var r io.ReadSeeker
+	if f.tmpPath != "" {
+		fh, err := os.Open(f.tmpPath)
 		if err != nil {
-			f.Error = err
+			f.discard()
+			f.Error = errors.Wrapf(err, "failed validating url [%s]", DisplayURL(f.SanitizedURL))
 			return
 		}
+		defer fh.Close()
+		r = fh
+	} else {
+		r = bytes.NewReader(f.FileBytes)
+	}
+
+	stats, err := image.Validate(r, maxPixels)
+	if err == image.ErrUnsupportedFormat {
+		log.Debugf("skipping validation of unsupported image format: url=%s type=%s", DisplayURL(f.SanitizedURL), f.ContentType)
+		return
+	}
+	if err != nil {
+		f.discard()
+		f.FileBytes = nil
+		f.Error = errors.Wrapf(err, "rejected url [%s]", DisplayURL(f.SanitizedURL))
+		return
+	}
+
+	f.Width, f.Height = stats.Width, stats.Height
+}
+
+// discard is a method for removing the temporary file of a File, if any
+func (f *File) discard() {
+	if f.tmpPath != "" {
+		os.Remove(f.tmpPath)
+		f.tmpPath = ""
 	}
+}
 
-	f.Size = len(fileBytes)
-	f.FileBytes = fileBytes
-	f.ContentType, err = getFileContentType(f.FileBytes)
+// save is a method for naming a retrieved file and, if File.Location is not an empty string,
+// moving its temporary file into place. Existing files are never overwritten; see commitFile.
+// shardDepth levels of subdirectories taken from the content hash are prepended to the name.
+func (f *File) save(namer Namer, index int, query string, shardDepth int) {
+	u, err := url.Parse(f.SanitizedURL)
 	if err != nil {
+		f.discard()
 		f.Error = err
 		return
 	}
+
+	name := namer.Name(&NameInfo{
+		URL:         u,
+		ContentType: f.ContentType,
+		Hash:        f.Hash,
+		Index:       index,
+		Query:       query,
+	})
+	name = shardPath(name, f.Hash, shardDepth)
+
+	if f.tmpPath == "" {
+		f.Name = name
+		return
+	}
+
+	f.Name, err = commitFile(f.Location, name, f.tmpPath, namer.Unique())
+	f.tmpPath = ""
+	if err != nil {
+		f.Error = errors.Wrapf(err, "failed writing file for url [%s]", DisplayURL(f.SanitizedURL))
+	}
 }
 
 // ToJSON is a function for exporting a File to JSON
@@ -114,14 +257,20 @@ func getFileContentType(fileBytes []byte) (string, error) {
 	// Only the first 512 bytes are used to sniff the content type.
 	buffer := make([]byte, 512)
 
-	_, err := reader.Read(buffer)
+	n, err := reader.Read(buffer)
 	if err != nil {
 		return "", err
 	}
 
 	// Use the net/http package's handy DectectContentType function. Always returns a valid
 	// content-type by returning "application/octet-stream" if no others seemed to match.
-	contentType := http.DetectContentType(buffer)
+	// Image formats it does not sniff e.g. TIFF are identified by their magic bytes.
+	contentType := http.DetectContentType(buffer[:n])
+	if contentType == "application/octet-stream" {
+		if f, ok := image.DetectFormat(buffer[:n]); ok {
+			contentType = f.ContentType
+		}
+	}
 
 	return contentType, nil
 }
@@ -4,16 +4,19 @@
  * File Created: Saturday, 11th April 2020 7:36:37 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Monday, 19th July 2021 8:20:42 pm
+ * Last Modified: Saturday, 17th October 2026 6:29:00 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package download
 
 import (
+	"context"
 	"path"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
 )
 
 const (
@@ -34,9 +37,33 @@ func TestFileGet(t *testing.T) {
 		t.Logf("Running test %s", name)
 
 		f := newFile(test.url, tmpStore)
-		f.get()
+		f.get(context.Background(), NewClient(nil), nil)
+		f.save(BasenameNamer{}, 1, "", 0)
 
 		assert.NoError(t, f.Error)
 		assert.FileExists(t, path.Join(f.Location, f.Name))
 	}
 }
+
+func TestGetFileContentType(t *testing.T) {
+	tiffImg, _ := test_utils.NewImage("image/tiff", 4, 4)
+	bmpImg, _ := test_utils.NewImage("image/bmp", 4, 4)
+
+	tests := map[string]struct {
+		b           []byte
+		contentType string
+	}{
+		"TIFF":    {tiffImg, "image/tiff"},
+		"BMP":     {bmpImg, "image/bmp"},
+		"WebP":    {test_utils.NewWebPImage(), "image/webp"},
+		"Unknown": {[]byte{0, 1, 2, 3}, "application/octet-stream"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		contentType, err := getFileContentType(test.b)
+		assert.NoError(t, err)
+		assert.Equal(t, test.contentType, contentType)
+	}
+}
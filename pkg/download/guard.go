@@ -0,0 +1,187 @@
+// Package download provides file download utilities
+/*
+ * File: guard.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:03:12 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:03:12 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// blockedNets are the address ranges refused by the Guard: unspecified, loopback, private,
+// shared (CGNAT), link-local (including the cloud metadata endpoints), multicast and reserved ranges
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// metadataIPs are cloud metadata addresses outside of the blocked ranges
+var metadataIPs = []net.IP{
+	net.ParseIP("168.63.129.16"), // Azure
+}
+
+// BlockedAddressError is an error returned for connections refused by the Guard
+type BlockedAddressError struct {
+	Host string
+	IP   net.IP
+}
+
+func (e *BlockedAddressError) Error() string {
+	return fmt.Sprintf("refusing to connect to non-public address %s of host [%s]", e.IP, e.Host)
+}
+
+// Guard is a struct for protecting against server-side request forgery by search results.
+// Guard refuses connections to private, loopback, link-local (including cloud metadata) and other
+// non-public addresses. Checks are made on the resolved address of every connection, so they apply
+// to every redirect and cannot be bypassed by DNS names resolving to internal addresses.
+type Guard struct {
+	// trustedHosts are host names exempt from the checks; '*.example.com' entries match subdomains
+	trustedHosts []string
+	// trustedNets are address ranges exempt from the checks
+	trustedNets []*net.IPNet
+}
+
+// NewGuard is a function for initializing a Guard.
+// The trusted entries are host names e.g. 'minio.internal' or '*.corp.example.com',
+// IP addresses or CIDR ranges which are explicitly trusted.
+func NewGuard(trusted ...string) (*Guard, error) {
+	g := &Guard{}
+
+	for _, t := range trusted {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+
+		if _, n, err := net.ParseCIDR(t); err == nil {
+			g.trustedNets = append(g.trustedNets, n)
+		} else if ip := net.ParseIP(t); ip != nil {
+			g.trustedNets = append(g.trustedNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		} else if strings.ContainsAny(t, "/:") {
+			return nil, fmt.Errorf("invalid trusted host: '%s'", t)
+		} else {
+			g.trustedHosts = append(g.trustedHosts, t)
+		}
+	}
+
+	return g, nil
+}
+
+// Check returns a *BlockedAddressError if ip is not a public address and not trusted
+func (g *Guard) Check(host string, ip net.IP) error {
+	for _, n := range g.trustedNets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+
+	if isPublicIP(ip) {
+		return nil
+	}
+
+	return &BlockedAddressError{Host: host, IP: ip}
+}
+
+// dialContext wraps the dial function of a dialer with the Guard's checks
+func (g *Guard) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		host = strings.ToLower(host)
+
+		if g.trustedHost(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		// Check the address actually dialed, after name resolution
+		guarded := *dialer
+		guarded.Control = func(network, address string, _ syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				return fmt.Errorf("unable to parse dialed address '%s'", address)
+			}
+			return g.Check(host, ip)
+		}
+
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// trustedHost is a helper function for checking if a host name is trusted
+func (g *Guard) trustedHost(host string) bool {
+	for _, t := range g.trustedHosts {
+		if strings.HasPrefix(t, "*.") {
+			if base := t[2:]; host == base || strings.HasSuffix(host, "."+base) {
+				return true
+			}
+		} else if host == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP is a helper function for checking if ip is a publicly routable address
+func isPublicIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	for _, m := range metadataIPs {
+		if m.Equal(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mustParseCIDRs is a helper function for parsing a list of constant CIDR ranges
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
@@ -0,0 +1,108 @@
+/*
+ * File: guard_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:03:33 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:03:33 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardCheck(t *testing.T) {
+	g, err := NewGuard("10.1.0.0/16", "192.168.1.20", "minio.internal")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		ip      string
+		blocked bool
+	}{
+		"Public IPv4":        {"93.184.216.34", false},
+		"Public IPv6":        {"2606:2800:220:1:248:1893:25c8:1946", false},
+		"Loopback":           {"127.0.0.1", true},
+		"Loopback IPv6":      {"::1", true},
+		"Private":            {"172.16.5.4", true},
+		"Metadata":           {"169.254.169.254", true},
+		"Azure Metadata":     {"168.63.129.16", true},
+		"Unspecified":        {"0.0.0.0", true},
+		"Unique Local IPv6":  {"fd00:ec2::254", true},
+		"IPv4-Mapped IPv6":   {"::ffff:10.0.0.1", true},
+		"Trusted Range":      {"10.1.2.3", false},
+		"Untrusted Neighbor": {"10.2.2.3", true},
+		"Trusted Address":    {"192.168.1.20", false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		err := g.Check("example.com", net.ParseIP(test.ip))
+		if test.blocked {
+			assert.IsType(t, &BlockedAddressError{}, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.True(t, g.trustedHost("minio.internal"))
+	assert.False(t, g.trustedHost("evil.minio.internal"))
+
+	_, err = NewGuard("http://minio.internal")
+	assert.Error(t, err)
+}
+
+func TestGuardClient(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer target.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	// The redirecting server is trusted by name; its redirect target is not
+	redirectURL := strings.Replace(redirect.URL, "127.0.0.1", "localhost", 1)
+
+	tests := map[string]struct {
+		trusted []string
+		url     string
+		blocked bool
+	}{
+		"Loopback":         {nil, target.URL, true},
+		"Trusted Address":  {[]string{"127.0.0.1"}, target.URL, false},
+		"Trusted Redirect": {[]string{"127.0.0.1"}, redirectURL, false},
+		"Blocked Redirect": {[]string{"localhost"}, redirectURL, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		opts := newTestClientOptions()
+		opts.Guard, _ = NewGuard(test.trusted...)
+
+		resp, err := NewClient(opts).Get(context.Background(), test.url)
+		if test.blocked {
+			assert.Error(t, err)
+			var blocked *BlockedAddressError
+			assert.True(t, errors.As(err, &blocked), "expected a BlockedAddressError; got %v", err)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+}
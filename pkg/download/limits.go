@@ -0,0 +1,67 @@
+// Package download provides file download utilities
+/*
+ * File: limits.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:01:14 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:25:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Limits is a struct for the safety limits applied to each download
+type Limits struct {
+	// MaxBytes is the maximum size of a file. Larger files are rejected by their Content-Length
+	// or aborted mid-stream. 0 disables the limit.
+	MaxBytes int64
+	// AllowedTypes are the content types accepted, matched against the type sniffed from the file's
+	// leading bytes rather than the server's Content-Type header. Entries may use a wildcard subtype
+	// e.g. 'image/*'. An empty list accepts every type.
+	AllowedTypes []string
+	// HeadCheck issues a HEAD request before each download to reject files exceeding MaxBytes
+	// without opening a download. Servers not supporting HEAD fall back to the GET checks.
+	HeadCheck bool
+	// AllowFiles allows 'file://' URLs to be read from the local file system. They are refused by default
+	// so URLs taken from search results cannot copy local files.
+	AllowFiles bool
+}
+
+// checkSize returns an error if size exceeds the maximum size. Unknown sizes (-1) pass.
+func (l *Limits) checkSize(size int64) error {
+	if l == nil || l.MaxBytes <= 0 || size <= l.MaxBytes {
+		return nil
+	}
+	return fmt.Errorf("file size %d exceeds the maximum of %d bytes", size, l.MaxBytes)
+}
+
+// checkType returns an error if the content type is not allowed
+func (l *Limits) checkType(contentType string) error {
+	if l == nil || len(l.AllowedTypes) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	mediaType = strings.ToLower(mediaType)
+
+	for _, allowed := range l.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == mediaType || allowed == "*/*" {
+			return nil
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("content type [%s] is not allowed; allowed types are %s", mediaType, strings.Join(l.AllowedTypes, ", "))
+}
@@ -0,0 +1,123 @@
+/*
+ * File: limits_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:02:08 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:02:08 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestFileGetLimits(t *testing.T) {
+	img, err := test_utils.NewImage("image/png", 64, 64)
+	assert.NoError(t, err)
+
+	var gets int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			atomic.AddInt32(&gets, 1)
+		}
+
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(img)
+		case "/page.png":
+			w.Write([]byte("<html><body>Not Found</body></html>"))
+		case "/stream.png":
+			// Chunked; no Content-Length for the pre-checks to reject
+			w.Write(img[:len(img)/2])
+			w.(http.Flusher).Flush()
+			w.Write(img[len(img)/2:])
+		}
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		path   string
+		limits *Limits
+		gets   int32
+		err    string
+	}{
+		"No Limits":            {"/image.png", nil, 1, ""},
+		"Allowed Type":         {"/image.png", &Limits{AllowedTypes: []string{"image/*"}}, 1, ""},
+		"Disallowed Type":      {"/page.png", &Limits{AllowedTypes: []string{"image/jpeg", "image/png"}}, 1, "not allowed"},
+		"Content-Length":       {"/image.png", &Limits{MaxBytes: int64(len(img) - 1)}, 1, "exceeds"},
+		"HEAD Content-Length":  {"/image.png", &Limits{MaxBytes: int64(len(img) - 1), HeadCheck: true}, 0, "exceeds"},
+		"Mid-Stream":           {"/stream.png", &Limits{MaxBytes: int64(len(img) - 1)}, 1, "exceeds"},
+		"Mid-Stream Exact Fit": {"/stream.png", &Limits{MaxBytes: int64(len(img))}, 1, ""},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		dir, err := ioutil.TempDir("", "emld-limits")
+		assert.NoError(t, err)
+
+		atomic.StoreInt32(&gets, 0)
+		f := newFile(server.URL+test.path, dir)
+		f.get(context.Background(), NewClient(newTestClientOptions()), test.limits)
+		f.save(BasenameNamer{}, 1, "", 0)
+
+		assert.Equal(t, test.gets, atomic.LoadInt32(&gets))
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		if test.err != "" {
+			if assert.Error(t, f.Error) {
+				assert.True(t, strings.Contains(f.Error.Error(), test.err), f.Error.Error())
+			}
+			// Rejected files leave nothing behind, including partial temporary files
+			assert.Empty(t, files)
+		} else {
+			assert.NoError(t, f.Error)
+			assert.Equal(t, len(img), f.Size)
+			assert.Equal(t, []string{filepath.Join(dir, f.Name)}, files)
+		}
+
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFileGetNoStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents"))
+	}))
+	defer server.Close()
+
+	f := newFile(server.URL+"/a.txt", "")
+	f.get(context.Background(), NewClient(newTestClientOptions()), nil)
+	f.save(BasenameNamer{}, 1, "", 0)
+
+	assert.NoError(t, f.Error)
+	assert.Equal(t, "contents", string(f.FileBytes))
+	assert.Equal(t, "a.txt", f.Name)
+}
+
+func TestLimitsCheckType(t *testing.T) {
+	l := &Limits{AllowedTypes: []string{"image/*", "application/pdf"}}
+
+	assert.NoError(t, l.checkType("image/png"))
+	assert.NoError(t, l.checkType("application/pdf"))
+	assert.Error(t, l.checkType("text/html; charset=utf-8"))
+	assert.Error(t, l.checkType("imagex/png"))
+
+	var unlimited *Limits
+	assert.NoError(t, unlimited.checkType("text/html"))
+	assert.NoError(t, unlimited.checkSize(1<<40))
+}
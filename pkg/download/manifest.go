@@ -0,0 +1,203 @@
+// Package download provides file download utilities
+/*
+ * File: manifest.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:57:38 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:02:23 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ManifestName is the name of the manifest file written to the destination path
+	ManifestName = ".emld-manifest.jsonl"
+
+	// StatusCompleted is the manifest status of a successfully downloaded file
+	StatusCompleted = "completed"
+	// StatusFailed is the manifest status of a file which could not be downloaded
+	StatusFailed = "failed"
+)
+
+// ManifestEntry is a struct for representing the outcome of a single download in the manifest
+type ManifestEntry struct {
+	URL         string `json:"url"`
+	Filename    string `json:"filename"`
+	Size        int    `json:"size"`
+	Hash        string `json:"hash"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	TimeStamp   int64  `json:"timestamp"`
+}
+
+// Manifest is an append-only JSON Lines record of the downloads into a destination path.
+// When a URL is recorded more than once, its latest entry wins. Manifest is safe for concurrent use.
+type Manifest struct {
+	path    string
+	fh      *os.File
+	entries map[string]*ManifestEntry
+	// order of the URLs as first recorded
+	order []string
+
+	sync.Mutex
+}
+
+// OpenManifest is a function for opening the manifest at path, creating it if it does not exist.
+// Malformed lines, e.g. a partially written final line after a crash, are skipped.
+func OpenManifest(path string) (*Manifest, error) {
+	m := &Manifest{
+		path:    path,
+		entries: map[string]*ManifestEntry{},
+	}
+
+	if fh, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(fh)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			var e ManifestEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.URL == "" {
+				log.Warnf("skipping malformed manifest entry: path=%s line=%d", path, line)
+				continue
+			}
+			m.set(&e)
+		}
+		err = scanner.Err()
+		fh.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	m.fh = fh
+
+	return m, nil
+}
+
+// OpenManifestIn is a function for opening the manifest of a destination path
+func OpenManifestIn(dst string) (*Manifest, error) {
+	return OpenManifest(filepath.Join(dst, ManifestName))
+}
+
+// Record appends the outcome of a processed File to the manifest
+func (m *Manifest) Record(f *File) error {
+	e := &ManifestEntry{
+		URL:         fileKey(f),
+		Filename:    f.Name,
+		Size:        f.Size,
+		Hash:        f.Hash,
+		ContentType: f.ContentType,
+		Width:       f.Width,
+		Height:      f.Height,
+		Status:      StatusCompleted,
+		TimeStamp:   time.Now().Unix(),
+	}
+	if f.Error != nil {
+		e.Status = StatusFailed
+		e.Error = f.Error.Error()
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, err := m.fh.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	m.set(e)
+
+	return nil
+}
+
+// Completed returns the entry of a URL if its latest download completed and the file
+// still exists under dir. Files without a location are not checked.
+func (m *Manifest) Completed(url, dir string) (*ManifestEntry, bool) {
+	m.Lock()
+	e, ok := m.entries[url]
+	m.Unlock()
+
+	if !ok || e.Status != StatusCompleted {
+		return nil, false
+	}
+
+	if dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(e.Filename))); err != nil {
+			return nil, false
+		}
+	}
+
+	return e, true
+}
+
+// Failed returns the URLs whose latest download failed, in the order they were first recorded.
+// data URIs are recorded by the hash of their contents so can't be replayed, and are left out.
+func (m *Manifest) Failed() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	failed := []string{}
+	for _, url := range m.order {
+		if m.entries[url].Status == StatusFailed && !strings.HasPrefix(url, dataKeyPrefix) {
+			failed = append(failed, url)
+		}
+	}
+
+	return failed
+}
+
+// Len returns the number of URLs recorded
+func (m *Manifest) Len() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.entries)
+}
+
+// Close closes the manifest file
+func (m *Manifest) Close() error {
+	return m.fh.Close()
+}
+
+func (m *Manifest) set(e *ManifestEntry) {
+	if _, ok := m.entries[e.URL]; !ok {
+		m.order = append(m.order, e.URL)
+	}
+	m.entries[e.URL] = e
+}
+
+// fileKey is a helper function for retrieving the manifest and cache key of a File (see sourceKey).
+// Files failing URL validation are keyed by their raw URL.
+func fileKey(f *File) string {
+	if f.key == "" {
+		u := f.SanitizedURL
+		if u == "" {
+			u = f.RawURL
+		}
+		f.key = sourceKey(u)
+	}
+	return f.key
+}
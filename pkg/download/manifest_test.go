@@ -0,0 +1,127 @@
+/*
+ * File: manifest_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:58:01 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:02:23 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-manifest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m, err := OpenManifestIn(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0666))
+	assert.NoError(t, m.Record(&File{SanitizedURL: "http://a.com/a.jpg", Name: "a.jpg", Size: 1, Hash: "ca978112"}))
+	assert.NoError(t, m.Record(&File{SanitizedURL: "http://a.com/b.jpg", Error: fmt.Errorf("timeout")}))
+	assert.NoError(t, m.Record(&File{RawURL: "not a url", Error: fmt.Errorf("invalid")}))
+	assert.NoError(t, m.Record(&File{SanitizedURL: "http://a.com/c.jpg", Name: "missing.jpg"}))
+	assert.NoError(t, m.Close())
+
+	// Simulate a partially written entry after a crash
+	fh, err := os.OpenFile(filepath.Join(dir, ManifestName), os.O_WRONLY|os.O_APPEND, 0666)
+	assert.NoError(t, err)
+	fh.WriteString(`{"url": "http://a.com/d.jp`)
+	fh.Close()
+
+	m, err = OpenManifestIn(dir)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	assert.Equal(t, 4, m.Len())
+	assert.Equal(t, []string{"http://a.com/b.jpg", "not a url"}, m.Failed())
+
+	e, ok := m.Completed("http://a.com/a.jpg", dir)
+	assert.True(t, ok)
+	assert.Equal(t, "ca978112", e.Hash)
+
+	// Completed entries whose file was removed are downloaded again
+	_, ok = m.Completed("http://a.com/c.jpg", dir)
+	assert.False(t, ok)
+	_, ok = m.Completed("http://a.com/b.jpg", dir)
+	assert.False(t, ok)
+
+	// The latest entry of a URL wins
+	assert.NoError(t, m.Record(&File{SanitizedURL: "http://a.com/b.jpg", Name: "a.jpg"}))
+	assert.Equal(t, []string{"not a url"}, m.Failed())
+
+	// Failed data URIs are recorded by their hash so are not replayed
+	assert.NoError(t, m.Record(&File{RawURL: "data:image/png;base64,iVBORw0KGgo=", Error: fmt.Errorf("invalid image")}))
+	assert.Equal(t, 5, m.Len())
+	assert.Equal(t, []string{"not a url"}, m.Failed())
+}
+
+func TestDownloaderResume(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "emld-resume")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	urls := []string{server.URL + "/a.jpg", server.URL + "/b.jpg"}
+
+	run := func(resume bool) []*File {
+		m, err := OpenManifestIn(dir)
+		assert.NoError(t, err)
+		defer m.Close()
+
+		d, err := NewDownloader(2, dir, false, false)
+		assert.NoError(t, err)
+		d.Manifest = m
+		d.Client = NewClient(newTestClientOptions())
+		d.Resume = resume
+
+		d.Start()
+		defer d.Stop()
+
+		var files []*File
+		for _, u := range urls {
+			d.InChan <- u
+		}
+		for range urls {
+			files = append(files, <-d.OutChan)
+		}
+		return files
+	}
+
+	run(false)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Completed URLs are skipped when resuming
+	for _, f := range run(true) {
+		assert.True(t, f.Cached)
+		assert.NotEmpty(t, f.Name)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Without resume, URLs are downloaded again without duplicating identical files
+	run(false)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*.jpg"))
+	assert.Len(t, names, 2)
+}
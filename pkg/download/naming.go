@@ -0,0 +1,373 @@
+// Package download provides file download utilities
+/*
+ * File: naming.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:55:44 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:25:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// NamingBasename names files with the sanitized basename of the URL
+	NamingBasename = "basename"
+	// NamingHash names files with the SHA-256 of their contents
+	NamingHash = "hash"
+	// NamingTemplate names files using a template e.g. '{host}/{hash8}_{basename}{ext}'
+	NamingTemplate = "template"
+
+	// maxNameLength is the maximum length of a single sanitized path element
+	maxNameLength = 200
+	// maxCollisions is the maximum number of collision suffixes tried before giving up
+	maxCollisions = 10000
+	// tmpPattern is the pattern of the temporary files downloads are streamed to
+	tmpPattern = ".emld-*.part"
+)
+
+// NameInfo is a struct for holding the details of a retrieved file available to a Namer
+type NameInfo struct {
+	// URL is the sanitized URL of the file
+	URL *url.URL
+	// ContentType is the sniffed content type of the file
+	ContentType string
+	// Hash is the hex encoded SHA-256 of the file bytes
+	Hash string
+	// Index is the 1-based sequence number assigned as a worker picks up the URL.
+	// With more than one worker this only approximates the input order.
+	Index int
+	// Query is the search query the URLs were fetched with, if known
+	Query string
+}
+
+// Namer is an interface for naming downloaded files.
+// Names are relative to the destination path and may contain '/' separated subdirectories.
+type Namer interface {
+	// Name returns the relative filepath of the file
+	Name(info *NameInfo) string
+	// Unique returns true if equal names imply equal contents, in which case an existing
+	// file is reused rather than suffixed
+	Unique() bool
+}
+
+// NewNamer is a function for initializing the Namer of the specified strategy.
+// The template is only used by the 'template' strategy.
+func NewNamer(strategy, template string) (Namer, error) {
+	switch strings.ToLower(strategy) {
+	case NamingBasename, "":
+		return BasenameNamer{}, nil
+	case NamingHash:
+		return HashNamer{}, nil
+	case NamingTemplate:
+		return NewTemplateNamer(template)
+	}
+
+	return nil, fmt.Errorf("unrecognized naming strategy: '%s'; must be one of basename, hash, template", strategy)
+}
+
+// BasenameNamer names files with the sanitized basename of the URL path.
+// The extension of the sniffed content type is appended if the basename has none.
+// data URIs have no basename and are named 'data_' followed by the first 8 characters of the content hash.
+type BasenameNamer struct{}
+
+// Name returns the sanitized basename
+func (BasenameNamer) Name(info *NameInfo) string {
+	base, ext := splitBasename(info.URL)
+	if info.URL.Scheme == schemeData && len(info.Hash) >= 8 {
+		base += "_" + info.Hash[:8]
+	}
+	if ext == "" {
+		ext = extensionFor(info.ContentType)
+	}
+
+	return base + ext
+}
+
+// Unique returns false; different files may share a basename
+func (BasenameNamer) Unique() bool { return false }
+
+// HashNamer names files with the SHA-256 of their contents and the extension of the sniffed content type
+type HashNamer struct{}
+
+// Name returns the content-addressed name
+func (HashNamer) Name(info *NameInfo) string {
+	ext := extensionFor(info.ContentType)
+	if ext == "" {
+		_, ext = splitBasename(info.URL)
+	}
+
+	return info.Hash + ext
+}
+
+// Unique returns true; equal names imply equal contents
+func (HashNamer) Unique() bool { return true }
+
+// templateRegexp matches template placeholders e.g. '{hash8}'
+var templateRegexp = regexp.MustCompile(`\{([a-z0-9]+)\}`)
+
+// templateFields are the placeholders supported by TemplateNamer
+var templateFields = map[string]func(info *NameInfo) string{
+	"host": func(info *NameInfo) string { return info.URL.Hostname() },
+	"hash": func(info *NameInfo) string { return info.Hash },
+	"hash8": func(info *NameInfo) string {
+		if len(info.Hash) < 8 {
+			return info.Hash
+		}
+		return info.Hash[:8]
+	},
+	"basename": func(info *NameInfo) string {
+		base, _ := splitBasename(info.URL)
+		return base
+	},
+	"ext": func(info *NameInfo) string {
+		if ext := extensionFor(info.ContentType); ext != "" {
+			return ext
+		}
+		_, ext := splitBasename(info.URL)
+		return ext
+	},
+	"query": func(info *NameInfo) string {
+		if info.Query == "" {
+			return "untitled"
+		}
+		return info.Query
+	},
+	"index": func(info *NameInfo) string { return fmt.Sprintf("%06d", info.Index) },
+}
+
+// TemplateNamer names files using a template of '/' separated path elements and placeholders:
+//
+//	{host}      host of the URL
+//	{hash}      SHA-256 of the file contents; {hash8} for the first 8 characters
+//	{basename}  sanitized basename of the URL without its extension
+//	{ext}       extension of the sniffed content type, including the leading '.'
+//	{query}     search query the URLs were fetched with
+//	{index}     zero padded order in which the URL was received
+//
+// If the template does not contain '{ext}', the extension is appended.
+type TemplateNamer struct {
+	template string
+}
+
+// NewTemplateNamer is a function for initializing and validating a TemplateNamer
+func NewTemplateNamer(template string) (*TemplateNamer, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("empty naming template")
+	}
+
+	for _, m := range templateRegexp.FindAllStringSubmatch(template, -1) {
+		if _, ok := templateFields[m[1]]; !ok {
+			return nil, fmt.Errorf("unrecognized naming template placeholder: '%s'", m[0])
+		}
+	}
+
+	if !strings.Contains(template, "{ext}") {
+		template += "{ext}"
+	}
+
+	return &TemplateNamer{template: template}, nil
+}
+
+// Name returns the expanded template.
+// Placeholder values are sanitized so only the template itself can introduce subdirectories.
+func (t *TemplateNamer) Name(info *NameInfo) string {
+	var elems []string
+	for _, elem := range strings.Split(t.template, "/") {
+		elem = templateRegexp.ReplaceAllStringFunc(elem, func(placeholder string) string {
+			value := templateFields[placeholder[1:len(placeholder)-1]](info)
+			if placeholder == "{ext}" {
+				return value
+			}
+			return sanitizeName(value)
+		})
+
+		if elem = sanitizeName(elem); elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+
+	return strings.Join(elems, "/")
+}
+
+// Unique returns true if the template contains the full content hash
+func (t *TemplateNamer) Unique() bool {
+	return strings.Contains(t.template, "{hash}")
+}
+
+// shardPath is a helper function for prefixing a name with depth levels of
+// subdirectories taken from the content hash e.g. 'ab/cd/name.jpg'
+func shardPath(name, hash string, depth int) string {
+	var elems []string
+	for i := 0; i < depth && len(hash) >= (i+1)*2; i++ {
+		elems = append(elems, hash[i*2:(i+1)*2])
+	}
+
+	return path.Join(append(elems, name)...)
+}
+
+// commitFile is a helper function for moving the temporary file at tmpPath to name under dir without
+// overwriting existing files. On collision, '_1', '_2', ... suffixes are tried before the extension.
+// If unique is true, an existing file of the same name is assumed to hold the same contents and reused;
+// otherwise existing files are only reused if their contents are identical.
+// The temporary file is always removed. Returns the name the file was moved to.
+func commitFile(dir, name, tmpPath string, unique bool) (string, error) {
+	defer os.Remove(tmpPath)
+
+	fullpath := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullpath), 0777); err != nil {
+		return "", err
+	}
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	for i := 0; i < maxCollisions; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		}
+		fullpath := filepath.Join(dir, filepath.FromSlash(candidate))
+
+		// A hard link atomically fails if the name exists; fall back to a rename on file systems without links
+		err := os.Link(tmpPath, fullpath)
+		if err != nil && !os.IsExist(err) {
+			if _, serr := os.Lstat(fullpath); os.IsNotExist(serr) {
+				err = os.Rename(tmpPath, fullpath)
+			} else {
+				err = os.ErrExist
+			}
+		}
+
+		if os.IsExist(err) {
+			if unique || sameContents(fullpath, tmpPath) {
+				return candidate, nil
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return candidate, nil
+	}
+
+	return "", fmt.Errorf("unable to find an unused name for '%s' after %d attempts", name, maxCollisions)
+}
+
+// sameContents is a helper function for checking if the files at a and b hold the same contents.
+// This keeps re-downloads of the same URL from accumulating suffixed copies.
+func sameContents(a, b string) bool {
+	ainfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	binfo, err := os.Stat(b)
+	if err != nil || ainfo.Size() != binfo.Size() {
+		return false
+	}
+
+	abytes, err := ioutil.ReadFile(a)
+	if err != nil {
+		return false
+	}
+	bbytes, err := ioutil.ReadFile(b)
+
+	return err == nil && bytes.Equal(abytes, bbytes)
+}
+
+// unsafeNameRegexp matches characters not allowed in sanitized names
+var unsafeNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// sanitizeName is a helper function for reducing a path element to a safe, portable file name
+func sanitizeName(name string) string {
+	name = unsafeNameRegexp.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(name, ".")
+
+	if len(name) > maxNameLength {
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		name = name[:maxNameLength-len(ext)] + ext
+	}
+
+	return name
+}
+
+// splitBasename is a helper function for splitting the sanitized basename of a URL path into its stem and extension.
+// URLs without a usable basename e.g. ending in '/' are named after their host; data URIs are named 'data'.
+func splitBasename(u *url.URL) (string, string) {
+	if u.Scheme == schemeData {
+		return schemeData, ""
+	}
+
+	// Split on the escaped path so encoded separators stay within the basename
+	base := path.Base(u.EscapedPath())
+	if base == "/" || base == "." {
+		base = ""
+	}
+	if unescaped, err := url.PathUnescape(base); err == nil {
+		base = unescaped
+	}
+
+	base = sanitizeName(base)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	if stem == "" || strings.Trim(stem, "_") == "" {
+		stem = sanitizeName(u.Hostname())
+		if stem == "" {
+			stem = "download"
+		}
+	}
+
+	return stem, strings.ToLower(ext)
+}
+
+// contentTypeExtensions maps common sniffed content types to their preferred extension
+var contentTypeExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"image/tiff":      ".tiff",
+	"image/x-icon":    ".ico",
+	"image/svg+xml":   ".svg",
+	"application/pdf": ".pdf",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+}
+
+// extensionFor is a helper function for retrieving the extension of a content type.
+// Returns an empty string for unknown and generic content types.
+func extensionFor(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" || strings.HasPrefix(mediaType, "text/plain") {
+		return ""
+	}
+
+	if ext, ok := contentTypeExtensions[mediaType]; ok {
+		return ext
+	}
+
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,101 @@
+/*
+ * File: naming_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 5:56:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:25:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func newTestNameInfo(rawURL, contentType string) *NameInfo {
+	u, _ := url.Parse(rawURL)
+	return &NameInfo{URL: u, ContentType: contentType, Hash: testHash, Index: 7, Query: "red boats"}
+}
+
+func TestNamers(t *testing.T) {
+	tests := map[string]struct {
+		strategy string
+		template string
+		url      string
+		expected string
+	}{
+		"Basename":               {NamingBasename, "", "http://a.com/img/Boat.JPG?w=100", "Boat.jpg"},
+		"Basename Sniffed Ext":   {NamingBasename, "", "http://a.com/img/photo", "photo.png"},
+		"Basename Trailing /":    {NamingBasename, "", "http://a.com/img/", "img.png"},
+		"Basename Root":          {NamingBasename, "", "http://a.com/", "a.com.png"},
+		"Basename Unsafe":        {NamingBasename, "", "http://a.com/..%2F..%2Fetc%20passwd.png", "_.._etc_passwd.png"},
+		"Basename Data URI":      {NamingBasename, "", "data:image/png;base64,iVBORw0KGgo=", "data_9f86d081.png"},
+		"Basename File URL":      {NamingBasename, "", "file:///data/images/boat.jpg", "boat.jpg"},
+		"Hash":                   {NamingHash, "", "http://a.com/boat.jpg", testHash + ".png"},
+		"Template Host":          {NamingTemplate, "{host}/{hash8}_{basename}{ext}", "http://cdn.a.com/boat.jpg", "cdn.a.com/9f86d081_boat.png"},
+		"Template Query Index":   {NamingTemplate, "{query}/{index}", "http://a.com/boat.jpg", "red_boats/000007.png"},
+		"Template No Traversal":  {NamingTemplate, "../{host}/{basename}", "http://a.com/boat.jpg", "a.com/boat.png"},
+		"Template Escaped Value": {NamingTemplate, "{basename}", "http://a.com/a%2Fb.jpg", "a_b.png"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		namer, err := NewNamer(test.strategy, test.template)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, namer.Name(newTestNameInfo(test.url, "image/png")))
+	}
+
+	// Invalid strategies and templates
+	_, err := NewNamer("uuid", "")
+	assert.Error(t, err)
+	_, err = NewNamer(NamingTemplate, "{host}/{size}")
+	assert.Error(t, err)
+	_, err = NewNamer(NamingTemplate, "")
+	assert.Error(t, err)
+}
+
+func TestShardPath(t *testing.T) {
+	assert.Equal(t, "boat.jpg", shardPath("boat.jpg", testHash, 0))
+	assert.Equal(t, "9f/86/boat.jpg", shardPath("boat.jpg", testHash, 2))
+	assert.Equal(t, "9f/boat.jpg", shardPath("boat.jpg", "9f", 3))
+}
+
+func TestCommitFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-download")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	commit := func(name, contents string, unique bool) string {
+		tmp := filepath.Join(dir, "tmp.part")
+		assert.NoError(t, ioutil.WriteFile(tmp, []byte(contents), 0644))
+
+		name, err := commitFile(dir, name, tmp, unique)
+		assert.NoError(t, err)
+		assert.NoFileExists(t, tmp)
+		return name
+	}
+
+	// Different contents are suffixed rather than overwritten
+	assert.Equal(t, "a.com/image.jpg", commit("a.com/image.jpg", "first", false))
+	assert.Equal(t, "a.com/image_1.jpg", commit("a.com/image.jpg", "second", false))
+
+	// Identical contents reuse the existing file
+	assert.Equal(t, "a.com/image_1.jpg", commit("a.com/image.jpg", "second", false))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "a.com", "image.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(b))
+
+	// Unique names are always reused
+	assert.Equal(t, "a.com/image.jpg", commit("a.com/image.jpg", "third", true))
+}
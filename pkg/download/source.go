@@ -0,0 +1,213 @@
+// Package download provides file download utilities
+/*
+ * File: source.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:23:52 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:02:23 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// schemeData is the scheme of URIs holding their contents inline e.g. 'data:image/png;base64,iVBOR...'
+	schemeData = "data"
+	// schemeFile is the scheme of local file URLs e.g. 'file:///data/images/cat.jpg'
+	schemeFile = "file"
+
+	// maxDisplayLength is the maximum length of a data URI included in errors
+	maxDisplayLength = 64
+	// dataKeyPrefix is the prefix of the manifest and cache keys of data URIs, followed by the hex SHA-256 of their contents
+	dataKeyPrefix = "data:sha256:"
+)
+
+// sourceScheme is a helper function for retrieving the lower case scheme of a URL
+func sourceScheme(urlStr string) string {
+	i := strings.Index(urlStr, ":")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(urlStr[:i])
+}
+
+// DisplayURL is a function for shortening data URIs included in errors, logs and output records
+func DisplayURL(urlStr string) string {
+	if sourceScheme(urlStr) != schemeData || len(urlStr) <= maxDisplayLength {
+		return urlStr
+	}
+	return urlStr[:maxDisplayLength] + "..."
+}
+
+// sourceKey is a helper function for deriving the manifest and cache key of a source URL.
+// data URIs are keyed by the SHA-256 of their contents e.g. 'data:sha256:<hex>' rather than held verbatim;
+// other URLs are their own key.
+func sourceKey(urlStr string) string {
+	if sourceScheme(urlStr) != schemeData {
+		return urlStr
+	}
+
+	data, _, err := parseDataURI(urlStr)
+	if err != nil {
+		// Invalid data URIs are keyed by the URI itself
+		data = []byte(urlStr)
+	}
+	sum := sha256.Sum256(data)
+	return dataKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// parseDataURI is a helper function for decoding the contents and media type of a data URI (RFC 2397).
+// The media type defaults to 'text/plain;charset=US-ASCII'. Base64 payloads may omit padding
+// and contain whitespace; other payloads are percent-decoded.
+func parseDataURI(uri string) ([]byte, string, error) {
+	if sourceScheme(uri) != schemeData {
+		return nil, "", fmt.Errorf("not a data URI [%s]", DisplayURL(uri))
+	}
+
+	comma := strings.Index(uri, ",")
+	if comma < 0 {
+		return nil, "", fmt.Errorf("data URI has no ',' separating its contents [%s]", DisplayURL(uri))
+	}
+	meta, payload := uri[len(schemeData)+1:comma], uri[comma+1:]
+
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		isBase64 = true
+		meta = meta[:len(meta)-len(";base64")]
+	}
+
+	mediaType := "text/plain;charset=US-ASCII"
+	if meta != "" {
+		if strings.HasPrefix(meta, ";") {
+			meta = "text/plain" + meta
+		}
+		mt, params, err := mime.ParseMediaType(meta)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid data URI media type [%s]", meta)
+		}
+		mediaType = mime.FormatMediaType(mt, params)
+	}
+
+	if strings.Contains(payload, "%") {
+		unescaped, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid data URI encoding [%s]", DisplayURL(uri))
+		}
+		payload = unescaped
+	}
+
+	if !isBase64 {
+		return []byte(payload), mediaType, nil
+	}
+
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, payload)
+
+	// Accept the URL safe alphabet too
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "invalid data URI base64 contents [%s]", DisplayURL(uri))
+	}
+
+	return data, mediaType, nil
+}
+
+// validateFileURL is a helper function for validating a file URL and returning its canonical form.
+// Only absolute paths on the local host are supported.
+func validateFileURL(urlStr string) (string, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse file URL [%s]", urlStr)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URL is not on the local host [%s]", urlStr)
+	}
+	if u.Opaque != "" || !path.IsAbs(u.Path) {
+		return "", fmt.Errorf("file URL is not an absolute path [%s]", urlStr)
+	}
+
+	return (&url.URL{Scheme: schemeFile, Path: path.Clean(u.Path)}).String(), nil
+}
+
+// open is a method for opening the contents of a File's source, returning the contents and their
+// size (-1 if unknown). data URIs are decoded inline, file URLs are read from the local file system
+// if allowed by the limits, and any other URL is requested with the client.
+func (f *File) open(ctx context.Context, client *Client, limits *Limits) (io.ReadCloser, int64, error) {
+	switch sourceScheme(f.SanitizedURL) {
+	case schemeData:
+		data, _, err := parseDataURI(f.SanitizedURL)
+		if err != nil {
+			return nil, -1, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+
+	case schemeFile:
+		if limits == nil || !limits.AllowFiles {
+			return nil, -1, fmt.Errorf("file URLs are not allowed [%s]", f.SanitizedURL)
+		}
+
+		u, err := url.Parse(f.SanitizedURL)
+		if err != nil {
+			return nil, -1, err
+		}
+		fh, err := os.Open(filepath.FromSlash(u.Path))
+		if err != nil {
+			return nil, -1, errors.Wrapf(err, "failed opening url [%s]", f.SanitizedURL)
+		}
+
+		// Refuse directories and devices e.g. '/dev/zero'
+		info, err := fh.Stat()
+		if err == nil && !info.Mode().IsRegular() {
+			err = fmt.Errorf("not a regular file")
+		}
+		if err != nil {
+			fh.Close()
+			return nil, -1, errors.Wrapf(err, "failed opening url [%s]", f.SanitizedURL)
+		}
+		return fh, info.Size(), nil
+	}
+
+	if limits != nil && limits.HeadCheck && limits.MaxBytes > 0 {
+		if resp, err := client.Head(ctx, f.SanitizedURL); err == nil {
+			resp.Body.Close()
+			if err := limits.checkSize(resp.ContentLength); err != nil {
+				return nil, -1, errors.Wrapf(err, "rejected url [%s]", f.SanitizedURL)
+			}
+		} else {
+			log.Debugf("HEAD pre-check failed for url [%s]; %s", f.SanitizedURL, err.Error())
+		}
+	}
+
+	resp, err := client.Get(ctx, f.SanitizedURL)
+	if err != nil {
+		return nil, -1, errors.Wrapf(err, "failed issuing a GET response for url [%s]", f.SanitizedURL)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
@@ -0,0 +1,173 @@
+/*
+ * File: source_test.go
+ * Project: download
+ * File Created: Saturday, 17th October 2026 6:25:02 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:54:36 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package download
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestParseDataURI(t *testing.T) {
+	tests := map[string]struct {
+		uri       string
+		data      string
+		mediaType string
+		err       bool
+	}{
+		"Base64":            {"data:image/png;base64,aGVsbG8=", "hello", "image/png", false},
+		"Base64 Unpadded":   {"data:image/png;base64,aGVsbG8", "hello", "image/png", false},
+		"Base64 Whitespace": {"data:image/png;base64,aGVs\nbG8=", "hello", "image/png", false},
+		"Base64 Escaped":    {"data:image/png;base64,aGVsbG8%3D", "hello", "image/png", false},
+		"Plain Text":        {"data:,hello%20world", "hello world", "text/plain;charset=US-ASCII", false},
+		"Charset Only":      {"data:;charset=utf-8,hi", "hi", "text/plain; charset=utf-8", false},
+		"Upper Case":        {"DATA:image/gif;BASE64,aGk=", "hi", "image/gif", false},
+		"No Comma":          {"data:image/png;base64", "", "", true},
+		"Invalid Base64":    {"data:image/png;base64,!!!!", "", "", true},
+		"Not Data":          {"http://a.com/a.png", "", "", true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		data, mediaType, err := parseDataURI(test.uri)
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.data, string(data))
+		assert.Equal(t, test.mediaType, mediaType)
+	}
+}
+
+func TestValidateSourceURL(t *testing.T) {
+	tests := map[string]struct {
+		url      string
+		expected string
+		err      bool
+	}{
+		"File":           {"file:///data/images/../cat.jpg", "file:///data/cat.jpg", false},
+		"File Localhost": {"file://localhost/data/cat.jpg", "file:///data/cat.jpg", false},
+		"File Remote":    {"file://nas/data/cat.jpg", "", true},
+		"File Relative":  {"file:cat.jpg", "", true},
+		"Data":           {"data:image/png;base64,aGk=", "data:image/png;base64,aGk=", false},
+		"Data Invalid":   {"data:image/png;base64", "", true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		u, err := validateURL(test.url)
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, u)
+	}
+}
+
+func TestDownloaderSources(t *testing.T) {
+	img, err := test_utils.NewImage("image/png", 16, 16)
+	assert.NoError(t, err)
+
+	src, err := ioutil.TempDir("", "emld-src")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+	dir, err := ioutil.TempDir("", "emld-sources")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	imgPath := filepath.Join(src, "local image.png")
+	assert.NoError(t, ioutil.WriteFile(imgPath, img, 0666))
+	fileURL := "file://" + filepath.ToSlash(imgPath)
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(img)
+
+	tests := map[string]struct {
+		url    string
+		limits *Limits
+		name   string
+		err    bool
+	}{
+		"Data URI":           {dataURI, nil, "data_", false},
+		"File URL":           {fileURL, &Limits{AllowFiles: true}, "local_image.png", false},
+		"File URL Refused":   {fileURL, nil, "", true},
+		"File URL Directory": {"file://" + filepath.ToSlash(src), &Limits{AllowFiles: true}, "", true},
+		"File URL Too Large": {fileURL, &Limits{AllowFiles: true, MaxBytes: 10}, "", true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		m, err := OpenManifestIn(dir)
+		assert.NoError(t, err)
+
+		d, err := NewDownloader(1, dir, false, false)
+		assert.NoError(t, err)
+		d.Client = NewClient(newTestClientOptions())
+		d.Limits = test.limits
+		d.Manifest = m
+		d.Start()
+
+		d.InChan <- test.url
+		f := <-d.OutChan
+		d.Close()
+		m.Close()
+
+		if test.err {
+			assert.Error(t, f.Error)
+			continue
+		}
+		assert.NoError(t, f.Error)
+		assert.Contains(t, f.Name, test.name)
+		assert.Equal(t, "image/png", f.ContentType)
+		assert.Equal(t, len(img), f.Size)
+
+		saved, err := ioutil.ReadFile(filepath.Join(dir, f.Name))
+		assert.NoError(t, err)
+		assert.Equal(t, img, saved)
+
+		// Records are kept in the manifest like any download
+		m, err = OpenManifestIn(dir)
+		assert.NoError(t, err)
+		e, ok := m.Completed(fileKey(f), dir)
+		if assert.True(t, ok) {
+			assert.Equal(t, f.Name, e.Filename)
+			assert.Less(t, len(e.URL), 256)
+		}
+		m.Close()
+	}
+}
+
+func TestSourceKey(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n"))
+
+	tests := map[string]struct {
+		url string
+		key string
+	}{
+		"HTTP":         {"http://example.com/a.jpg", "http://example.com/a.jpg"},
+		"Data URI":     {"data:image/png;base64," + png, "data:sha256:4c4b6a3be1314ab86138bef4314dde022e600960d8689a2c8f8631802d20dab6"},
+		"Same Content": {"data:image/png;base64," + strings.TrimRight(png, "="), "data:sha256:4c4b6a3be1314ab86138bef4314dde022e600960d8689a2c8f8631802d20dab6"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+		assert.Equal(t, test.key, sourceKey(test.url))
+	}
+}
@@ -5,7 +5,7 @@
  * File Created: Sunday, 29th March 2020 9:10:19 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Tuesday, 7th April 2020 4:42:55 pm
+ * Last Modified: Saturday, 17th October 2026 6:25:21 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package download
@@ -23,7 +23,20 @@ import (
 // If no URL scheme is found, the url is returned with prefixed forward slashes
 // removed. This is to prevent download errors related to commonly prefixed forward
 // slashed returned from Searx e.g. //live.staticflickr.com/5338/6912241516_ba31a52ea0_c.jpg
+//
+// data URIs are validated by decoding them and returned as is; file URLs are returned in their
+// canonical form e.g. 'file:///data/images/cat.jpg'.
 func validateURL(urlStr string) (string, error) {
+	switch sourceScheme(urlStr) {
+	case schemeData:
+		if _, _, err := parseDataURI(urlStr); err != nil {
+			return "", err
+		}
+		return urlStr, nil
+	case schemeFile:
+		return validateFileURL(urlStr)
+	}
+
 	u, err := url.ParseRequestURI(urlStr)
 	if err != nil {
 		return "", errors.Wrapf(err, "unabled to parse request URI [%s]", urlStr)
@@ -41,14 +54,3 @@ func validateURL(urlStr string) (string, error) {
 
 	return urlStr, nil
 }
-
-// stripQueryString is a helper function for stripping the query params from a url
-// This is useful when trying to parse out a filename from a url
-func stripQueryString(urlStr string) (string, error) {
-	u, err := url.Parse(urlStr)
-	if err != nil {
-		return "", err
-	}
-	u.RawQuery = ""
-	return u.String(), nil
-}
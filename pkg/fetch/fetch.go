@@ -5,50 +5,59 @@
  * File Created: Wednesday, 18th March 2020 8:37:31 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Thursday, 28th May 2020 10:20:46 pm
+ * Last Modified: Saturday, 17th October 2026 6:51:24 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package fetch
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/cache"
 )
 
+// DefaultConcurrency is the default maximum number of in-flight queries of a Fetcher
+const DefaultConcurrency = 8
+
 // Fetcher is a struct for holding a fetcher's context variables
 // We only allow one type per fetcher instance here so that each
 // Fetcher can be configured individually for a specific content type.
 type Fetcher struct {
-	// Searx connection address
-	SearxAddr string
+	// Searcher is the search backend queries are executed against
+	Searcher Searcher
 	// Type of search to execute
 	Type string
 	// Languages to search in
 	Languages []string
-	// Stream results as they are received
-	StreamResults bool
+	// Concurrency is the maximum number of in-flight queries
+	Concurrency int
+	// Filter removes unwanted results before de-duplication. Optional.
+	Filter *Filter
+	// Params are the optional search parameters applied to every query
+	Params QueryParams
 
-	// HashMap used for filtering out duplicate image sources
-	cache map[string]bool
-	sync.Mutex
+	// Cache used for filtering out duplicate image sources.
+	// Defaults to an in-memory cache; set a persistent cache to de-dup across runs.
+	Cache cache.Cache
 
 	// TODO: add in image fuzzy de-duping: https://godoc.org/github.com/rivo/duplo#Match
 }
 
 // NewFetcher creates a new Fetcher object
+// The searcher selects the backend implementation e.g. as returned from NewSearcher.
 func NewFetcher(
-	searxAddr, contentType string,
-	languagesAll, languagesSimplified, streamResults bool,
+	searcher Searcher, contentType string,
+	languagesAll, languagesSimplified bool,
 	languages ...string) (*Fetcher, error) {
 
 	// HealthCheck
-	if !searxHealthCheck(searxAddr) {
-		return nil, fmt.Errorf("unable to reach Searx at '%s'. Is a Searx server running at the specified address?", searxAddr)
+	if err := searcher.HealthCheck(); err != nil {
+		return nil, err
 	}
 
 	// Check content type
@@ -85,107 +94,183 @@ func NewFetcher(
 	}
 
 	return &Fetcher{
-		SearxAddr:     searxAddr,
-		Type:          contentType,
-		Languages:     langCodes,
-		StreamResults: streamResults,
-		cache:         make(map[string]bool),
+		Searcher:    searcher,
+		Type:        contentType,
+		Languages:   langCodes,
+		Concurrency: DefaultConcurrency,
+		Cache:       cache.NewMemory(0),
 	}, nil
 }
 
-// FetchAsync is a method for asynchronously executing a content query
-// for the first or a specified set of results.
-func (f *Fetcher) FetchAsync(query string, pageNo ...int) *Result {
-	if len(pageNo) == 0 {
-		pageNo[0] = 1
-	} else {
-		if pageNo[0] == 0 {
-			pageNo[0] = 1
-		}
+// ValidateParams is a method for checking the Fetcher's search parameters against the capabilities
+// advertised by the search backend. Returns an error if parameters are set on a backend which does not support them.
+func (f *Fetcher) ValidateParams() error {
+	if f.Params.IsZero() {
+		return nil
+	}
+
+	validator, ok := f.Searcher.(ParamValidator)
+	if !ok {
+		return fmt.Errorf("the '%s' backend does not support engines, safe search or time range parameters", f.Searcher.Name())
+	}
+
+	return validator.ValidateParams(f.Type, &f.Params)
+}
+
+// FetchAsync is a method for asynchronously executing a content query for the specified page of results.
+//
+// Filtered results are sent on the returned results channel as they are received from the backend.
+// The results channel is closed once all queries have completed or ctx is done, after which the
+// completed Result, including any errors, is sent on the returned done channel. The caller must
+// drain the results channel for the queries to make progress.
+func (f *Fetcher) FetchAsync(ctx context.Context, query string, pageNo int) (<-chan SearxResult, <-chan *Result) {
+	if pageNo == 0 {
+		pageNo = 1
 	}
 
 	result := &Result{
-		Query:    query,
-		ResultNo: 0,
-		PageNo:   pageNo[0],
-		Ready:    false,
+		Query:       query,
+		ResultNo:    0,
+		PageNo:      pageNo,
+		QueryParams: f.Params,
 	}
 
-	var errs []error
+	resultsChan := make(chan SearxResult)
+	doneChan := make(chan *Result, 1)
+
 	var wg sync.WaitGroup
 
+	// Bound the number of in-flight queries
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = len(f.Languages)
+	}
+	sem := make(chan struct{}, concurrency)
+
 	// Queue up a goroutine for each lang code
 	for _, lang := range f.Languages {
 
 		wg.Add(1)
-		go func(lang string, r *Result, wg *sync.WaitGroup) {
+		go func(lang string) {
 			defer wg.Done()
 
-			log.Infof("search: query=%s, type=%s, lang=%s, pageNo=%d\n", query, f.Type, lang, pageNo[0])
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			log.Infof("search: backend=%s, query=%s, type=%s, lang=%s, pageNo=%d\n", f.Searcher.Name(), query, f.Type, lang, pageNo)
 
-			results, err := searxQuery(f.SearxAddr, query, f.Type, lang, pageNo[0])
+			results, err := f.Searcher.Query(ctx, &Query{Text: query, Category: f.Type, Lang: lang, PageNo: pageNo, QueryParams: f.Params})
 			if err != nil {
-				errs = append(errs, fmt.Errorf("unexpected error in Searx query with query '%s' (lang=%s); err=%s", query, lang, err))
-			} else {
-				// Filter results using url cache
-				filteredResults := []SearxResult{}
-
-				f.Lock()
-				for _, r := range results {
-					if _, ok := f.cache[r.URL]; !ok {
-						f.cache[r.URL] = true
-						filteredResults = append(filteredResults, r)
-					} else {
-						log.Debugf("filtering out: %s", r.URL)
-					}
+				// Cancellation is reported once for the whole Result
+				if ctx.Err() == nil {
+					result.addError(fmt.Errorf("unexpected error in %s query with query '%s' (lang=%s); err=%s", f.Searcher.Name(), query, lang, err))
 				}
-				f.Unlock()
+				return
+			}
+
+			results = f.Searcher.Normalize(results)
+			if f.Filter != nil {
+				results = f.Filter.Apply(results)
+			}
+
+			// Filter results using url cache
+			filteredResults, err := f.filterCached(results)
+			if err != nil {
+				result.addError(fmt.Errorf("unable to filter results with query '%s' (lang=%s); err=%s", query, lang, err))
+				return
+			}
 
-				if len(filteredResults) == 0 {
+			for i, r := range filteredResults {
+				select {
+				case resultsChan <- r:
+				case <-ctx.Done():
+					// Unsent results were claimed in the cache; release them for a later fetch
+					f.releaseCached(filteredResults[i:]...)
 					return
 				}
 
 				// Update result
 				result.Lock()
-				result.Results = append(result.Results, filteredResults...)
-				result.ResultNo += len(filteredResults)
+				result.Results = append(result.Results, r)
+				result.ResultNo++
 				result.Unlock()
-
-				// Stream if specified
-				if f.StreamResults {
-					for _, res := range filteredResults {
-						j, err := json.MarshalIndent(res, "", "\t")
-						if err != nil {
-							continue
-						}
-						fmt.Fprint(os.Stdout, string(j)+"\n")
-					}
-				}
 			}
-		}(lang, result, &wg)
+		}(lang)
 	}
 
-	// Goroutine for updating fetch.Result Ready & Errors attributes
-	go func(r *Result, wg *sync.WaitGroup) {
+	// Goroutine for completing the fetch.Result once all queries have returned
+	go func() {
 		wg.Wait()
-		result.Lock()
-		result.Ready = true
-		result.Errors = append(result.Errors, errs...)
-		result.Unlock()
+		close(resultsChan)
+
+		if err := ctx.Err(); err != nil {
+			result.addError(fmt.Errorf("fetch interrupted with query '%s' (pageNo=%d); err=%s", query, pageNo, err))
+		}
 
-	}(result, &wg)
+		doneChan <- result
+		close(doneChan)
+	}()
 
-	return result
+	return resultsChan, doneChan
 }
 
-// ClearCache is a function to clear a Fetcher's URL cache
-func (f *Fetcher) ClearCache() {
-	for k := range f.cache {
-		delete(f.cache, k)
+// Fetch is a method for synchronously executing a content query for the specified page of results.
+// Fetch blocks until all queries have completed or ctx is done.
+func (f *Fetcher) Fetch(ctx context.Context, query string, pageNo int) *Result {
+	results, done := f.FetchAsync(ctx, query, pageNo)
+	for range results {
 	}
+
+	return <-done
 }
 
-// CacheLen is a function to retrieve a Fetcher's URL cache length
-func (f *Fetcher) CacheLen() int {
-	return len(f.cache)
+// filterCached is a helper function for filtering out results whose image source was already seen
+func (f *Fetcher) filterCached(results []SearxResult) ([]SearxResult, error) {
+	srcs := make([]string, len(results))
+	for i, r := range results {
+		srcs[i] = r.ImgSrc
+	}
+
+	claimed, err := f.Cache.Claim(cache.Fetched, srcs...)
+	if err != nil {
+		return nil, err
+	}
+
+	isClaimed := make(map[string]bool, len(claimed))
+	for _, src := range claimed {
+		isClaimed[src] = true
+	}
+
+	filteredResults := []SearxResult{}
+	for _, r := range results {
+		if isClaimed[r.ImgSrc] {
+			filteredResults = append(filteredResults, r)
+			// Only emit the first of any duplicates within the same response
+			delete(isClaimed, r.ImgSrc)
+		} else {
+			log.Debugf("filtering out: %s", r.ImgSrc)
+		}
+	}
+
+	return filteredResults, nil
+}
+
+// releaseCached is a helper function for releasing the cache claims of results which were not emitted
+func (f *Fetcher) releaseCached(results ...SearxResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	srcs := make([]string, len(results))
+	for i, r := range results {
+		srcs[i] = r.ImgSrc
+	}
+
+	if err := f.Cache.Release(cache.Fetched, srcs...); err != nil {
+		log.Warnf("unable to release %d unsent results from the cache; err=%s", len(srcs), err)
+	}
 }
@@ -0,0 +1,123 @@
+/*
+ * File: fetch_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:33:25 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:51:24 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubSearcher is a Searcher returning a fixed set of results for every query
+type stubSearcher struct {
+	results []SearxResult
+	delay   time.Duration
+}
+
+func (s *stubSearcher) Name() string       { return "stub" }
+func (s *stubSearcher) HealthCheck() error { return nil }
+func (s *stubSearcher) Normalize(results []SearxResult) []SearxResult {
+	return normalizeResults(results)
+}
+func (s *stubSearcher) Query(ctx context.Context, q *Query) ([]SearxResult, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.results, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newStubResults(n int) []SearxResult {
+	results := []SearxResult{}
+	for i := 0; i < n; i++ {
+		src := fmt.Sprintf("http://example.com/%d.jpg", i)
+		results = append(results, SearxResult{URL: src, ImgSrc: src})
+	}
+	return results
+}
+
+func TestFetchAsync(t *testing.T) {
+	f, err := NewFetcher(&stubSearcher{results: newStubResults(10)}, "images", false, false, "english", "french")
+	assert.NoError(t, err)
+
+	results, done := f.FetchAsync(context.Background(), "boats", 1)
+
+	streamed := 0
+	for range results {
+		streamed++
+	}
+	res := <-done
+
+	// Duplicates across languages are filtered out
+	assert.Equal(t, 10, streamed)
+	assert.Equal(t, 10, res.ResultNo)
+	assert.False(t, res.HasErrors())
+}
+
+func TestFetchCancel(t *testing.T) {
+	f, err := NewFetcher(&stubSearcher{results: newStubResults(10), delay: time.Minute}, "images", false, false, "english")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	res := f.Fetch(ctx, "boats", 1)
+
+	assert.Equal(t, 0, res.ResultNo)
+	assert.True(t, res.HasErrors())
+}
+
+func TestFetchCancelRelease(t *testing.T) {
+	f, err := NewFetcher(&stubSearcher{results: newStubResults(10)}, "images", false, false, "english")
+	assert.NoError(t, err)
+
+	// Cancel after the first result; results which were never sent are not recorded as fetched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, done := f.FetchAsync(ctx, "boats", 1)
+
+	seen := map[string]bool{}
+	for r := range results {
+		seen[r.ImgSrc] = true
+		cancel()
+	}
+	<-done
+	assert.Less(t, len(seen), 10)
+
+	for _, r := range f.Fetch(context.Background(), "boats", 1).Results {
+		assert.False(t, seen[r.ImgSrc], "result %s emitted twice", r.ImgSrc)
+		seen[r.ImgSrc] = true
+	}
+	assert.Len(t, seen, 10)
+}
+
+func TestFetcherValidateParams(t *testing.T) {
+	f, err := NewFetcher(&stubSearcher{results: newStubResults(1)}, "images", false, false, "english")
+	assert.NoError(t, err)
+
+	// No parameters are always valid
+	assert.NoError(t, f.ValidateParams())
+
+	// Parameters on a backend without a ParamValidator are rejected rather than silently ignored
+	f.Params = QueryParams{SafeSearch: SafeSearchStrict}
+	assert.Error(t, f.ValidateParams())
+
+	// Parameters are recorded in the Result
+	f.Params = QueryParams{TimeRange: "week"}
+	results, done := f.FetchAsync(context.Background(), "boats", 1)
+	for range results {
+	}
+	res := <-done
+	assert.Equal(t, "week", res.TimeRange)
+}
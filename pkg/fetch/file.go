@@ -0,0 +1,121 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: file.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:31:51 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:34:44 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// filePageSize is the number of entries returned per page by the file backend
+const filePageSize = 100
+
+// fileSearcher is the Searcher implementation for a local file of results.
+// Each line of the file is either a bare URL or a JSON encoded SearxResult.
+// The file backend has no notion of relevance; the query text, language
+// and category are ignored and the file entries are paged in order.
+type fileSearcher struct {
+	filepath string
+}
+
+// NewFileSearcher is a function for initializing a Searcher reading results from a local file
+func NewFileSearcher(filepath string) Searcher {
+	return &fileSearcher{filepath: strings.TrimPrefix(filepath, "file://")}
+}
+
+// Name returns the backend identifier
+func (s *fileSearcher) Name() string {
+	return BackendFile
+}
+
+// HealthCheck returns an error if the file is not readable
+func (s *fileSearcher) HealthCheck() error {
+	f, err := os.Open(s.filepath)
+	if err != nil {
+		return fmt.Errorf("unable to read result file '%s'; %s", s.filepath, err.Error())
+	}
+	return f.Close()
+}
+
+// Query returns the requested page of file entries
+func (s *fileSearcher) Query(ctx context.Context, q *Query) ([]SearxResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start := (q.PageNo - 1) * filePageSize
+	end := start + filePageSize
+
+	results := []SearxResult{}
+
+	lineNo := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if lineNo >= start && lineNo < end {
+			r, err := parseFileEntry(line)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse result file entry %d; %s", lineNo+1, err.Error())
+			}
+			results = append(results, r)
+		}
+
+		lineNo++
+		if lineNo >= end {
+			break
+		}
+	}
+
+	return results, scanner.Err()
+}
+
+// Normalize fills in missing image formats from the source extension and applies the shared post processing
+func (s *fileSearcher) Normalize(results []SearxResult) []SearxResult {
+	for i := range results {
+		if results[i].ImgFmt == "" {
+			src := strings.SplitN(results[i].ImgSrc, "?", 2)[0]
+			results[i].ImgFmt = strings.TrimPrefix(path.Ext(src), ".")
+		}
+	}
+
+	return normalizeResults(results)
+}
+
+// parseFileEntry is a helper function for parsing a single line of a result file
+func parseFileEntry(line string) (SearxResult, error) {
+	var r SearxResult
+
+	if strings.HasPrefix(line, "{") {
+		err := json.Unmarshal([]byte(line), &r)
+		return r, err
+	}
+
+	r.URL = line
+	r.ImgSrc = line
+	r.Engine = BackendFile
+
+	return r, nil
+}
@@ -0,0 +1,306 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: filter.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:44:48 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:44:48 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FilterConfig is a struct for declaring the result filtering rules.
+//
+// Domains are matched against the hosts of both the result page and the image source.
+// A domain of the form '*.example.com' matches example.com and all of its subdomains.
+// Results with an unknown resolution are not removed by the minimum resolution rule.
+type FilterConfig struct {
+	EngineAllow  []string `yaml:"engine_allow"`
+	EngineDeny   []string `yaml:"engine_deny"`
+	DomainAllow  []string `yaml:"domain_allow"`
+	DomainDeny   []string `yaml:"domain_deny"`
+	TitleInclude []string `yaml:"title_include"`
+	TitleExclude []string `yaml:"title_exclude"`
+	FormatAllow  []string `yaml:"format_allow"`
+	MinWidth     int      `yaml:"min_width"`
+	MinHeight    int      `yaml:"min_height"`
+}
+
+// LoadFilterConfig is a function for reading a FilterConfig from a YAML file
+func LoadFilterConfig(filepath string) (*FilterConfig, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg FilterConfig
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse filter config '%s'; %s", filepath, err.Error())
+	}
+
+	return &cfg, nil
+}
+
+// RuleStats is a struct for representing the number of results removed by a filter rule
+type RuleStats struct {
+	Rule    string `json:"rule"`
+	Removed int    `json:"removed"`
+}
+
+// filterRule is a struct for representing a single filter rule
+type filterRule struct {
+	name    string
+	keep    func(r *SearxResult) bool
+	removed int
+}
+
+// Filter is a struct for removing unwanted results before they are emitted.
+// Filter is safe for concurrent use.
+type Filter struct {
+	rules []*filterRule
+	sync.Mutex
+}
+
+// NewFilter is a function for compiling a FilterConfig into a Filter
+func NewFilter(cfg *FilterConfig) (*Filter, error) {
+	f := &Filter{}
+
+	if len(cfg.EngineAllow) > 0 {
+		allow := toSet(cfg.EngineAllow)
+		f.add("engine_allow", func(r *SearxResult) bool { return allow[strings.ToLower(r.Engine)] })
+	}
+
+	if len(cfg.EngineDeny) > 0 {
+		deny := toSet(cfg.EngineDeny)
+		f.add("engine_deny", func(r *SearxResult) bool { return !deny[strings.ToLower(r.Engine)] })
+	}
+
+	if len(cfg.DomainAllow) > 0 {
+		allow := cfg.DomainAllow
+		f.add("domain_allow", func(r *SearxResult) bool { return matchResultDomain(r, allow) })
+	}
+
+	if len(cfg.DomainDeny) > 0 {
+		deny := cfg.DomainDeny
+		f.add("domain_deny", func(r *SearxResult) bool { return !matchResultDomain(r, deny) })
+	}
+
+	if len(cfg.TitleInclude) > 0 {
+		include, err := compileAll(cfg.TitleInclude)
+		if err != nil {
+			return nil, err
+		}
+		f.add("title_include", func(r *SearxResult) bool { return matchAny(include, r.Title) })
+	}
+
+	if len(cfg.TitleExclude) > 0 {
+		exclude, err := compileAll(cfg.TitleExclude)
+		if err != nil {
+			return nil, err
+		}
+		f.add("title_exclude", func(r *SearxResult) bool { return !matchAny(exclude, r.Title) })
+	}
+
+	if len(cfg.FormatAllow) > 0 {
+		allow := map[string]bool{}
+		for _, format := range cfg.FormatAllow {
+			allow[normalizeFormat(format)] = true
+		}
+		f.add("format_allow", func(r *SearxResult) bool { return allow[resultFormat(r)] })
+	}
+
+	if cfg.MinWidth > 0 || cfg.MinHeight > 0 {
+		minWidth, minHeight := cfg.MinWidth, cfg.MinHeight
+		f.add("min_resolution", func(r *SearxResult) bool {
+			w, h, ok := parseResolution(r.Resolution)
+			if !ok {
+				w, h, ok = parseResolution(r.ImgFmt)
+			}
+			return !ok || (w >= minWidth && h >= minHeight)
+		})
+	}
+
+	return f, nil
+}
+
+// Apply returns the results passing all filter rules.
+// Each removed result is attributed to the first rule it failed.
+func (f *Filter) Apply(results []SearxResult) []SearxResult {
+	kept := []SearxResult{}
+
+	f.Lock()
+	defer f.Unlock()
+
+	for i := range results {
+		passed := true
+		for _, rule := range f.rules {
+			if !rule.keep(&results[i]) {
+				rule.removed++
+				passed = false
+				break
+			}
+		}
+
+		if passed {
+			kept = append(kept, results[i])
+		}
+	}
+
+	return kept
+}
+
+// Stats returns the number of results removed by each rule, in rule order
+func (f *Filter) Stats() []RuleStats {
+	f.Lock()
+	defer f.Unlock()
+
+	stats := []RuleStats{}
+	for _, rule := range f.rules {
+		stats = append(stats, RuleStats{Rule: rule.name, Removed: rule.removed})
+	}
+
+	return stats
+}
+
+// Len returns the number of rules
+func (f *Filter) Len() int {
+	return len(f.rules)
+}
+
+func (f *Filter) add(name string, keep func(r *SearxResult) bool) {
+	f.rules = append(f.rules, &filterRule{name: name, keep: keep})
+}
+
+// resolutionRegexp matches resolutions of the form '1920 x 1080', '1920x1080' or '1920 × 1080'
+var resolutionRegexp = regexp.MustCompile(`(\d+)\s*[x×X]\s*(\d+)`)
+
+// parseResolution is a helper function for parsing the width and height from a Searx resolution string
+func parseResolution(s string) (int, int, bool) {
+	m := resolutionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false
+	}
+
+	w, _ := strconv.Atoi(m[1])
+	h, _ := strconv.Atoi(m[2])
+
+	return w, h, true
+}
+
+// resultFormat is a helper function for determining the image format of a result,
+// falling back to the image source extension if the result's format is not a format name
+func resultFormat(r *SearxResult) string {
+	for _, token := range strings.Fields(r.ImgFmt) {
+		if _, _, ok := parseResolution(token); !ok {
+			if format := normalizeFormat(token); format != "" {
+				return format
+			}
+		}
+	}
+
+	if u, err := url.Parse(r.ImgSrc); err == nil {
+		return normalizeFormat(path.Ext(u.Path))
+	}
+
+	return ""
+}
+
+// normalizeFormat is a helper function for normalizing format names and extensions e.g. '.JPG' -> 'jpeg'
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	format = strings.TrimPrefix(format, "image/")
+
+	switch format {
+	case "jpg", "jpe", "jfif":
+		return "jpeg"
+	case "tif":
+		return "tiff"
+	}
+
+	return format
+}
+
+// matchResultDomain is a helper function for checking if the result page or image source host matches any domain pattern
+func matchResultDomain(r *SearxResult, patterns []string) bool {
+	for _, raw := range []string{r.URL, r.ImgSrc} {
+		if strings.HasPrefix(raw, "//") {
+			raw = "http:" + raw
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+
+		if matchDomain(strings.ToLower(u.Hostname()), patterns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDomain is a helper function for matching a host against domain patterns.
+// A pattern of the form '*.example.com' matches example.com and all of its subdomains.
+func matchDomain(host string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+
+		if strings.HasPrefix(p, "*.") {
+			base := p[2:]
+			if host == base || strings.HasSuffix(host, "."+base) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileAll is a helper function for compiling a list of regular expressions
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title pattern '%s'; %s", expr, err.Error())
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchAny is a helper function for checking if s matches any of the regular expressions
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// toSet is a helper function for converting a list of names into a lower cased set
+func toSet(names []string) map[string]bool {
+	set := map[string]bool{}
+	for _, n := range names {
+		set[strings.ToLower(strings.TrimSpace(n))] = true
+	}
+	return set
+}
@@ -0,0 +1,111 @@
+/*
+ * File: filter_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:48:45 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:48:45 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterApply(t *testing.T) {
+	results := []SearxResult{
+		{URL: "https://en.wikipedia.org/wiki/Cat", ImgSrc: "https://upload.wikimedia.org/cat.jpg", Engine: "bing images", Title: "Cat", ImgFmt: "jpeg", Resolution: "1920 x 1080"},
+		{URL: "https://www.pinterest.com/pin/1", ImgSrc: "https://i.pinimg.com/cat.png", Engine: "google images", Title: "Cat clipart", Resolution: "800x600"},
+		{URL: "https://example.com/cats", ImgSrc: "https://example.com/small.gif", Engine: "bing images", Title: "Small cat", Resolution: "120 × 90"},
+		{URL: "https://example.com/dogs", ImgSrc: "https://example.com/dog.JPG", Engine: "flickr", Title: "Dog"},
+	}
+
+	tests := map[string]struct {
+		cfg   FilterConfig
+		kept  []int
+		stats map[string]int
+	}{
+		"No Rules":     {FilterConfig{}, []int{0, 1, 2, 3}, map[string]int{}},
+		"Engine Allow": {FilterConfig{EngineAllow: []string{"Bing Images"}}, []int{0, 2}, map[string]int{"engine_allow": 2}},
+		"Engine Deny":  {FilterConfig{EngineDeny: []string{"flickr"}}, []int{0, 1, 2}, map[string]int{"engine_deny": 1}},
+		"Domain Allow Wildcard": {
+			FilterConfig{DomainAllow: []string{"*.wikipedia.org"}}, []int{0}, map[string]int{"domain_allow": 3},
+		},
+		"Domain Deny Image Host": {
+			FilterConfig{DomainDeny: []string{"*.pinimg.com", "example.com"}}, []int{0}, map[string]int{"domain_deny": 3},
+		},
+		"Title Regex": {
+			FilterConfig{TitleInclude: []string{"(?i)cat"}, TitleExclude: []string{"clipart"}}, []int{0, 2},
+			map[string]int{"title_include": 1, "title_exclude": 1},
+		},
+		"Format Allow": {FilterConfig{FormatAllow: []string{"JPG"}}, []int{0, 3}, map[string]int{"format_allow": 2}},
+		"Min Resolution": {
+			FilterConfig{MinWidth: 640, MinHeight: 480}, []int{0, 1, 3}, map[string]int{"min_resolution": 1},
+		},
+		"First Failing Rule": {
+			FilterConfig{EngineDeny: []string{"bing images"}, MinWidth: 640}, []int{1, 3},
+			map[string]int{"engine_deny": 2, "min_resolution": 0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		filter, err := NewFilter(&test.cfg)
+		assert.NoError(t, err)
+
+		var expected []SearxResult
+		for _, i := range test.kept {
+			expected = append(expected, results[i])
+		}
+
+		assert.ElementsMatch(t, expected, filter.Apply(results))
+
+		stats := map[string]int{}
+		for _, s := range filter.Stats() {
+			stats[s.Rule] = s.Removed
+		}
+		assert.Equal(t, test.stats, stats)
+	}
+}
+
+func TestNewFilterInvalidRegexp(t *testing.T) {
+	_, err := NewFilter(&FilterConfig{TitleExclude: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestLoadFilterConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-filter")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "filters.yaml")
+	assert.NoError(t, ioutil.WriteFile(valid, []byte(`
+engine_deny: [pinterest]
+domain_allow: ["*.wikimedia.org"]
+format_allow: [jpeg, png]
+min_width: 640
+`), 0644))
+
+	cfg, err := LoadFilterConfig(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, &FilterConfig{
+		EngineDeny:  []string{"pinterest"},
+		DomainAllow: []string{"*.wikimedia.org"},
+		FormatAllow: []string{"jpeg", "png"},
+		MinWidth:    640,
+	}, cfg)
+
+	// Unknown keys are rejected so typos don't silently disable a rule
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, ioutil.WriteFile(invalid, []byte("min_widht: 640\n"), 0644))
+
+	_, err = LoadFilterConfig(invalid)
+	assert.Error(t, err)
+}
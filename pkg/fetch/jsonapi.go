@@ -0,0 +1,204 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: jsonapi.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:31:42 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:34:44 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// FieldMapping is a struct for describing how a generic JSON API maps onto a SearxResult
+type FieldMapping struct {
+	// Results is the dot-delimited path to the array of results in the response body.
+	// An empty path indicates that the response body itself is the array of results.
+	Results string
+	// Fields maps SearxResult JSON field names e.g. 'img_src' to dot-delimited paths within each result
+	Fields map[string]string
+
+	// QueryParam is the request parameter holding the search string
+	QueryParam string
+	// PageParam is the request parameter holding the page number
+	PageParam string
+	// LangParam is the request parameter holding the language code. Omitted if empty.
+	LangParam string
+	// CategoryParam is the request parameter holding the content type. Omitted if empty.
+	CategoryParam string
+}
+
+// DefaultFieldMapping returns a FieldMapping for an API using the same field names as Searx
+func DefaultFieldMapping() *FieldMapping {
+	return &FieldMapping{
+		Results: "results",
+		Fields: map[string]string{
+			"url":           "url",
+			"img_format":    "img_format",
+			"img_src":       "img_src",
+			"thumbnail_src": "thumbnail_src",
+			"engine":        "engine",
+			"source":        "source",
+			"title":         "title",
+		},
+		QueryParam: "q",
+		PageParam:  "page",
+		LangParam:  "lang",
+	}
+}
+
+// jsonSearcher is the Searcher implementation for a generic JSON-over-HTTP search API
+type jsonSearcher struct {
+	addr    string
+	mapping *FieldMapping
+}
+
+// NewJSONSearcher is a function for initializing a Searcher for a generic JSON-over-HTTP API.
+// If mapping is nil, the DefaultFieldMapping is used.
+func NewJSONSearcher(addr string, mapping *FieldMapping) (Searcher, error) {
+	if mapping == nil {
+		mapping = DefaultFieldMapping()
+	}
+
+	if mapping.QueryParam == "" {
+		return nil, fmt.Errorf("invalid field mapping; no query parameter defined")
+	}
+
+	if _, ok := mapping.Fields["img_src"]; !ok {
+		return nil, fmt.Errorf("invalid field mapping; no path defined for field 'img_src'")
+	}
+
+	return &jsonSearcher{addr: addr, mapping: mapping}, nil
+}
+
+// Name returns the backend identifier
+func (s *jsonSearcher) Name() string {
+	return BackendJSON
+}
+
+// HealthCheck returns an error if the API is unreachable
+func (s *jsonSearcher) HealthCheck() error {
+	resp, err := http.Get(s.addr)
+	if err != nil {
+		return fmt.Errorf("unable to reach JSON API at '%s'; %s", s.addr, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unable to reach JSON API at '%s'; status=%d", s.addr, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Query executes a query against the API and maps the returned results using the field mapping
+func (s *jsonSearcher) Query(ctx context.Context, q *Query) ([]SearxResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.addr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+
+	params := req.URL.Query()
+	params.Add(s.mapping.QueryParam, q.Text)
+	if s.mapping.PageParam != "" {
+		params.Add(s.mapping.PageParam, fmt.Sprintf("%d", q.PageNo))
+	}
+	if s.mapping.LangParam != "" {
+		params.Add(s.mapping.LangParam, q.Lang)
+	}
+	if s.mapping.CategoryParam != "" {
+		params.Add(s.mapping.CategoryParam, q.Category)
+	}
+	req.URL.RawQuery = params.Encode()
+
+	client := &http.Client{}
+
+	rawResp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rawResp.Body.Close()
+
+	body, err := ioutil.ReadAll(rawResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mapping.apply(body)
+}
+
+// Normalize applies the shared post processing
+func (s *jsonSearcher) Normalize(results []SearxResult) []SearxResult {
+	return normalizeResults(results)
+}
+
+// apply is a method for extracting the mapped results from a JSON response body
+func (m *FieldMapping) apply(body []byte) ([]SearxResult, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	items, ok := lookupPath(doc, m.Results).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no result array found at path '%s'", m.Results)
+	}
+
+	results := []SearxResult{}
+	for _, item := range items {
+		// Build an intermediate object keyed on the SearxResult JSON field names
+		fields := map[string]string{}
+		for field, p := range m.Fields {
+			switch v := lookupPath(item, p).(type) {
+			case nil:
+			case string:
+				fields[field] = v
+			default:
+				fields[field] = fmt.Sprint(v)
+			}
+		}
+
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		var r SearxResult
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// lookupPath is a helper function for resolving a dot-delimited path within a decoded JSON document.
+// Returns nil if the path does not exist.
+func lookupPath(doc interface{}, p string) interface{} {
+	if p == "" {
+		return doc
+	}
+
+	for _, key := range strings.Split(p, ".") {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		doc = obj[key]
+	}
+
+	return doc
+}
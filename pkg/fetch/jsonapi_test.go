@@ -0,0 +1,70 @@
+/*
+ * File: jsonapi_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:32:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:32:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldMappingApply(t *testing.T) {
+	mapping := &FieldMapping{
+		Results: "data.items",
+		Fields: map[string]string{
+			"img_src": "media.url",
+			"title":   "caption",
+			"url":     "page",
+		},
+		QueryParam: "q",
+	}
+
+	tests := map[string]struct {
+		body    string
+		results []SearxResult
+		err     bool
+	}{
+		"Nested Results": {
+			`{"data": {"items": [{"media": {"url": "http://a.com/1.jpg"}, "caption": "boat", "page": "http://a.com"}]}}`,
+			[]SearxResult{{ImgSrc: "http://a.com/1.jpg", Title: "boat", URL: "http://a.com"}},
+			false,
+		},
+		"Missing Fields": {
+			`{"data": {"items": [{"caption": 42}]}}`,
+			[]SearxResult{{Title: "42"}},
+			false,
+		},
+		"Missing Results": {`{"data": {}}`, nil, true},
+		"Invalid JSON":    {`{"data": `, nil, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		results, err := mapping.apply([]byte(test.body))
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.results, results)
+	}
+}
+
+func TestNormalizeResults(t *testing.T) {
+	results := normalizeResults([]SearxResult{
+		{ImgSrc: "http://a.com/1.jpg"},
+		{URL: "http://a.com"},
+	})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "aHR0cDovL2EuY29tLzEuanBn", results[0].ImgSrcB64)
+}
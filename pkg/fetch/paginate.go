@@ -0,0 +1,156 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: paginate.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:43:24 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:52:31 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"fmt"
+)
+
+// StopReason is a type for representing why a target-count fetch stopped paginating
+type StopReason string
+
+const (
+	// StopTarget indicates the target number of unique results was collected
+	StopTarget StopReason = "target reached"
+	// StopExhausted indicates consecutive pages returned no new results
+	StopExhausted StopReason = "results exhausted"
+	// StopMaxPages indicates the page cap was reached
+	StopMaxPages StopReason = "max pages reached"
+	// StopCancelled indicates the fetch was cancelled or hit its deadline
+	StopCancelled StopReason = "cancelled"
+)
+
+// TargetOptions is a struct for configuring a target-count fetch
+type TargetOptions struct {
+	// Target is the number of unique results to collect
+	Target int
+	// StartPage is the first page to fetch
+	StartPage int
+	// MaxPages is the maximum number of pages to fetch. 0 for no cap.
+	MaxPages int
+	// Patience is the number of consecutive pages without new results after which to stop
+	Patience int
+}
+
+// Summary is a struct for representing the outcome of a target-count fetch
+type Summary struct {
+	Query     string     `json:"query"`
+	Target    int        `json:"target"`
+	Collected int        `json:"collected"`
+	Pages     int        `json:"pages"`
+	LastPage  int        `json:"last_page"`
+	Reason    StopReason `json:"reason"`
+	Errors    []error    `json:"-"`
+}
+
+// String returns a human readable summary
+func (s *Summary) String() string {
+	return fmt.Sprintf("fetch stopped (%s): query=%s, collected=%d/%d, pages=%d, last_page=%d, errors=%d",
+		s.Reason, s.Query, s.Collected, s.Target, s.Pages, s.LastPage, len(s.Errors))
+}
+
+// FetchTarget is a method for paginating a content query across all configured languages until
+// the target number of unique results has been collected.
+//
+// Pagination stops early once opts.Patience consecutive pages return no new results after
+// de-duplication, or opts.MaxPages pages have been fetched. Results are sent on the returned
+// results channel as they are received; the channel is closed once pagination stops, after which
+// the Summary is sent on the returned done channel. The caller must drain the results channel.
+func (f *Fetcher) FetchTarget(ctx context.Context, query string, opts TargetOptions) (<-chan SearxResult, <-chan *Summary) {
+	if opts.StartPage <= 0 {
+		opts.StartPage = 1
+	}
+	if opts.Patience <= 0 {
+		opts.Patience = 1
+	}
+
+	resultsChan := make(chan SearxResult)
+	doneChan := make(chan *Summary, 1)
+
+	go func() {
+		defer close(doneChan)
+		defer close(resultsChan)
+
+		summary := &Summary{Query: query, Target: opts.Target}
+		defer func() { doneChan <- summary }()
+
+		// Cancelling the page context stops the remaining queries once the target is reached
+		pageCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		empty := 0
+		for page := opts.StartPage; ; page++ {
+			if ctx.Err() != nil {
+				summary.Reason = StopCancelled
+				return
+			}
+			if opts.MaxPages > 0 && summary.Pages >= opts.MaxPages {
+				summary.Reason = StopMaxPages
+				return
+			}
+
+			results, done := f.FetchAsync(pageCtx, query, page)
+
+			collected := 0
+			var unsent []SearxResult
+			for r := range results {
+				if summary.Collected >= opts.Target {
+					unsent = append(unsent, r)
+					continue
+				}
+
+				select {
+				case resultsChan <- r:
+					summary.Collected++
+					collected++
+				case <-ctx.Done():
+					unsent = append(unsent, r)
+				}
+
+				if summary.Collected >= opts.Target {
+					cancel()
+				}
+			}
+
+			// Surplus results of the final page were claimed in the cache; release them for a later fetch
+			f.releaseCached(unsent...)
+
+			res := <-done
+			summary.Pages++
+			summary.LastPage = page
+
+			if summary.Collected >= opts.Target {
+				summary.Reason = StopTarget
+				return
+			}
+			if ctx.Err() != nil {
+				summary.Reason = StopCancelled
+				return
+			}
+
+			summary.Errors = append(summary.Errors, res.Errors...)
+
+			if collected == 0 {
+				empty++
+			} else {
+				empty = 0
+			}
+
+			if empty >= opts.Patience {
+				summary.Reason = StopExhausted
+				return
+			}
+		}
+	}()
+
+	return resultsChan, doneChan
+}
@@ -0,0 +1,112 @@
+/*
+ * File: paginate_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:43:58 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:52:31 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/cache"
+)
+
+// pagedSearcher is a Searcher returning perPage unique results for each of the first pages pages
+type pagedSearcher struct {
+	stubSearcher
+	perPage int
+	pages   int
+}
+
+func (s *pagedSearcher) Query(ctx context.Context, q *Query) ([]SearxResult, error) {
+	results := []SearxResult{}
+	if q.PageNo > s.pages {
+		return results, nil
+	}
+
+	for i := 0; i < s.perPage; i++ {
+		src := fmt.Sprintf("http://example.com/%d/%d.jpg", q.PageNo, i)
+		results = append(results, SearxResult{URL: src, ImgSrc: src})
+	}
+	return results, nil
+}
+
+func TestFetchTarget(t *testing.T) {
+	tests := map[string]struct {
+		searcher  Searcher
+		opts      TargetOptions
+		collected int
+		pages     int
+		reason    StopReason
+	}{
+		"Target Reached": {&pagedSearcher{perPage: 10, pages: 100}, TargetOptions{Target: 25, MaxPages: 10}, 25, 3, StopTarget},
+		"Max Pages":      {&pagedSearcher{perPage: 10, pages: 100}, TargetOptions{Target: 100, MaxPages: 4}, 40, 4, StopMaxPages},
+		"Dried Up":       {&pagedSearcher{perPage: 10, pages: 2}, TargetOptions{Target: 100, Patience: 2}, 20, 4, StopExhausted},
+		"Duplicates":     {&stubSearcher{results: newStubResults(10)}, TargetOptions{Target: 100, Patience: 3}, 10, 4, StopExhausted},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		f, err := NewFetcher(test.searcher, "images", false, false, "english", "french")
+		assert.NoError(t, err)
+
+		results, done := f.FetchTarget(context.Background(), "boats", test.opts)
+
+		streamed := 0
+		for range results {
+			streamed++
+		}
+		summary := <-done
+
+		assert.Equal(t, test.collected, streamed)
+		assert.Equal(t, test.collected, summary.Collected)
+		assert.Equal(t, test.pages, summary.Pages)
+		assert.Equal(t, test.reason, summary.Reason)
+	}
+}
+
+func TestFetchTargetSurplus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-fetch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := cache.Open(filepath.Join(dir, "cache.db"), 0)
+	assert.NoError(t, err)
+
+	fetchTarget := func(opts TargetOptions) []string {
+		f, err := NewFetcher(&pagedSearcher{perPage: 10, pages: 100}, "images", false, false, "english", "french")
+		assert.NoError(t, err)
+		f.Cache = c
+
+		results, done := f.FetchTarget(context.Background(), "boats", opts)
+		srcs := []string{}
+		for r := range results {
+			srcs = append(srcs, r.ImgSrc)
+		}
+		<-done
+		return srcs
+	}
+
+	// The target is reached half way through the third page
+	first := fetchTarget(TargetOptions{Target: 25})
+	assert.Len(t, first, 25)
+
+	// The surplus of the third page is output by the next run
+	second := fetchTarget(TargetOptions{Target: 10, StartPage: 3, MaxPages: 1})
+	assert.Len(t, second, 5)
+	for _, src := range second {
+		assert.NotContains(t, first, src)
+	}
+}
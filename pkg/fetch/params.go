@@ -0,0 +1,111 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: params.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:49:36 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:49:36 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SafeSearchOff disables safe search filtering
+	SafeSearchOff = "off"
+	// SafeSearchModerate enables moderate safe search filtering
+	SafeSearchModerate = "moderate"
+	// SafeSearchStrict enables strict safe search filtering
+	SafeSearchStrict = "strict"
+)
+
+// safeSearchLevels maps the safe search names to the Searx 'safesearch' levels
+var safeSearchLevels = map[string]int{
+	SafeSearchOff:      0,
+	SafeSearchModerate: 1,
+	SafeSearchStrict:   2,
+}
+
+// timeRanges are the Searx 'time_range' values
+var timeRanges = []string{"day", "week", "month", "year"}
+
+// QueryParams is a struct for the optional search parameters applied to every query of a Fetcher.
+// Zero values leave the backend defaults in place.
+type QueryParams struct {
+	// Engines pins the search engines queried e.g. 'bing images'
+	Engines []string `json:"engines,omitempty"`
+	// SafeSearch is the safe search level; one of 'off', 'moderate' or 'strict'
+	SafeSearch string `json:"safesearch,omitempty"`
+	// TimeRange restricts results to those published within the range; one of 'day', 'week', 'month' or 'year'
+	TimeRange string `json:"time_range,omitempty"`
+}
+
+// IsZero returns true if no parameters are set
+func (p *QueryParams) IsZero() bool {
+	return len(p.Engines) == 0 && p.SafeSearch == "" && p.TimeRange == ""
+}
+
+// ParamValidator is an interface implemented by Searchers able to check the query parameters
+// against the capabilities advertised by the backend.
+type ParamValidator interface {
+	// ValidateParams returns an error if the parameters are not supported for the category
+	ValidateParams(category string, params *QueryParams) error
+}
+
+// NewQueryParams is a function for initializing and validating the format of QueryParams.
+// The safe search level may be specified by name or as the Searx level 0, 1 or 2.
+func NewQueryParams(engines []string, safeSearch, timeRange string) (*QueryParams, error) {
+	params := &QueryParams{}
+
+	for _, e := range engines {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			params.Engines = append(params.Engines, e)
+		}
+	}
+
+	if safeSearch != "" {
+		level, err := ParseSafeSearch(safeSearch)
+		if err != nil {
+			return nil, err
+		}
+		params.SafeSearch = level
+	}
+
+	if timeRange != "" {
+		timeRange = strings.ToLower(timeRange)
+		if !contains(timeRanges, timeRange) {
+			return nil, fmt.Errorf("unrecognized time range: '%s'; must be one of %s", timeRange, strings.Join(timeRanges, ", "))
+		}
+		params.TimeRange = timeRange
+	}
+
+	return params, nil
+}
+
+// ParseSafeSearch is a function for parsing a safe search level from its name or Searx level
+func ParseSafeSearch(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	for name, level := range safeSearchLevels {
+		if s == name || s == fmt.Sprintf("%d", level) {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("unrecognized safe search level: '%s'; must be one of off (0), moderate (1), strict (2)", s)
+}
+
+// contains is a helper function for checking if a string slice contains s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: pool.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:41:56 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:41:56 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
+)
+
+// EndpointStats is a struct for representing the query statistics of a single Searx endpoint
+type EndpointStats struct {
+	Addr       string        `json:"addr"`
+	Healthy    bool          `json:"healthy"`
+	Requests   int           `json:"requests"`
+	Failures   int           `json:"failures"`
+	Ejections  int           `json:"ejections"`
+	AvgLatency time.Duration `json:"avg_latency"`
+}
+
+// StatsReporter is an interface for Searchers reporting per-endpoint statistics
+type StatsReporter interface {
+	Stats() []EndpointStats
+}
+
+// endpoint is a struct for tracking the health of a single Searx endpoint
+type endpoint struct {
+	addr    string
+	limiter *throttle.Bucket
+
+	requests     int
+	failures     int
+	ejections    int
+	latency      time.Duration
+	consecutive  int
+	ejectedUntil time.Time
+	sync.Mutex
+}
+
+// endpointPool is a struct for spreading queries across Searx endpoints.
+//
+// Endpoints are selected round-robin among the healthy endpoints. An endpoint is ejected
+// for EjectDuration after FailureThreshold consecutive failures (passive health tracking),
+// or when the periodic health check fails (active health tracking). Ejected endpoints are
+// reinstated once a health check succeeds or the ejection expires.
+type endpointPool struct {
+	endpoints []*endpoint
+	opts      *SearxOptions
+	client    *http.Client
+	next      uint32
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newEndpointPool is a function for initializing an endpointPool and starting its health checks
+func newEndpointPool(addrs []string, client *http.Client, opts *SearxOptions) *endpointPool {
+	p := &endpointPool{opts: opts, client: client}
+	for _, addr := range addrs {
+		p.endpoints = append(p.endpoints, &endpoint{
+			addr:    addr,
+			limiter: throttle.NewBucket(opts.Rate, opts.Burst),
+		})
+	}
+
+	if opts.HealthInterval > 0 {
+		var ctx context.Context
+		ctx, p.cancel = context.WithCancel(context.Background())
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.healthLoop(ctx)
+		}()
+	}
+
+	return p
+}
+
+// pick returns the next healthy endpoint. If all endpoints are ejected,
+// the endpoint whose ejection expires first is returned.
+func (p *endpointPool) pick() *endpoint {
+	n := len(p.endpoints)
+	start := int(atomic.AddUint32(&p.next, 1)-1) % n
+	now := time.Now()
+
+	var fallback *endpoint
+	var fallbackUntil time.Time
+
+	for i := 0; i < n; i++ {
+		ep := p.endpoints[(start+i)%n]
+
+		ep.Lock()
+		until := ep.ejectedUntil
+		ep.Unlock()
+
+		if !now.Before(until) {
+			return ep
+		}
+		if fallback == nil || until.Before(fallbackUntil) {
+			fallback, fallbackUntil = ep, until
+		}
+	}
+
+	return fallback
+}
+
+// record updates an endpoint's statistics with the outcome of a query
+func (p *endpointPool) record(ep *endpoint, latency time.Duration, failed bool) {
+	ep.Lock()
+	defer ep.Unlock()
+
+	ep.requests++
+	ep.latency += latency
+
+	if !failed {
+		ep.consecutive = 0
+		return
+	}
+
+	ep.failures++
+	ep.consecutive++
+	if p.opts.FailureThreshold > 0 && ep.consecutive >= p.opts.FailureThreshold {
+		p.eject(ep, fmt.Sprintf("%d consecutive failures", ep.consecutive))
+	}
+}
+
+// eject marks an endpoint as unhealthy for the ejection duration. The endpoint must be locked.
+func (p *endpointPool) eject(ep *endpoint, reason string) {
+	if time.Now().Before(ep.ejectedUntil) {
+		return
+	}
+
+	log.Warnf("ejecting Searx endpoint [%s] for %s; %s", ep.addr, p.opts.EjectDuration, reason)
+	ep.ejections++
+	ep.consecutive = 0
+	ep.ejectedUntil = time.Now().Add(p.opts.EjectDuration)
+}
+
+// healthLoop periodically health checks all endpoints until ctx is done
+func (p *endpointPool) healthLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkAll()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkAll health checks all endpoints, ejecting or reinstating them accordingly.
+// Returns the number of healthy endpoints.
+func (p *endpointPool) checkAll() int {
+	var healthy int32
+	var wg sync.WaitGroup
+
+	for _, ep := range p.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+
+			ok := searxHealthCheck(p.client, ep.addr)
+
+			ep.Lock()
+			defer ep.Unlock()
+
+			if !ok {
+				p.eject(ep, "health check failed")
+				return
+			}
+
+			atomic.AddInt32(&healthy, 1)
+			if !ep.ejectedUntil.IsZero() {
+				log.Infof("reinstating Searx endpoint [%s]", ep.addr)
+				ep.ejectedUntil = time.Time{}
+				ep.consecutive = 0
+			}
+		}(ep)
+	}
+
+	wg.Wait()
+
+	return int(healthy)
+}
+
+// stats returns a snapshot of the statistics of all endpoints
+func (p *endpointPool) stats() []EndpointStats {
+	now := time.Now()
+	stats := []EndpointStats{}
+
+	for _, ep := range p.endpoints {
+		ep.Lock()
+		s := EndpointStats{
+			Addr:      ep.addr,
+			Healthy:   !now.Before(ep.ejectedUntil),
+			Requests:  ep.requests,
+			Failures:  ep.failures,
+			Ejections: ep.ejections,
+		}
+		if ep.requests > 0 {
+			s.AvgLatency = ep.latency / time.Duration(ep.requests)
+		}
+		ep.Unlock()
+
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+// close stops the health checks
+func (p *endpointPool) close() {
+	if p.cancel != nil {
+		p.cancel()
+		p.wg.Wait()
+	}
+}
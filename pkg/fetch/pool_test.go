@@ -0,0 +1,118 @@
+/*
+ * File: pool_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:42:47 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:05:19 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
+)
+
+func TestSearxLoadBalancing(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(searxTestResponse))
+	}))
+	defer healthy.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	opts := &SearxOptions{
+		Timeout:          time.Second,
+		Retry:            throttle.Policy{MaxRetries: 1},
+		FailureThreshold: 2,
+		EjectDuration:    time.Minute,
+	}
+
+	s, err := NewSearxSearcher([]string{healthy.URL, failing.URL}, opts)
+	assert.NoError(t, err)
+	defer s.(*searxSearcher).Close()
+
+	// The failing endpoint is ejected by the initial health check
+	assert.NoError(t, s.HealthCheck())
+
+	for i := 0; i < 10; i++ {
+		results, err := s.Query(context.Background(), &Query{Text: "boats", Lang: "en", PageNo: 1})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	}
+
+	stats := s.(StatsReporter).Stats()
+	assert.Equal(t, EndpointStats{Addr: healthy.URL, Healthy: true, Requests: 10}, stats[0].withoutLatency())
+	assert.Equal(t, EndpointStats{Addr: failing.URL, Healthy: false, Ejections: 1}, stats[1].withoutLatency())
+}
+
+func TestSearxPassiveEjection(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(searxTestResponse))
+	}))
+	defer server.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(searxTestResponse))
+	}))
+	defer other.Close()
+
+	opts := &SearxOptions{Timeout: time.Second, FailureThreshold: 2, EjectDuration: time.Minute}
+	s, _ := NewSearxSearcher([]string{server.URL, other.URL}, opts)
+	assert.NoError(t, s.HealthCheck())
+
+	// Round-robin across both endpoints; once one fails repeatedly, all queries go to the other
+	healthy = false
+	for i := 0; i < 10; i++ {
+		s.Query(context.Background(), &Query{Text: "boats", Lang: "en", PageNo: 1})
+	}
+
+	stats := s.(StatsReporter).Stats()
+	assert.Equal(t, 2, stats[0].Failures)
+	assert.Equal(t, 1, stats[0].Ejections)
+	assert.False(t, stats[0].Healthy)
+	assert.Equal(t, 8, stats[1].Requests)
+}
+
+func TestSearxClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	opts := &SearxOptions{Timeout: time.Second, FailureThreshold: 2, EjectDuration: time.Minute}
+	s, err := NewSearxSearcher([]string{server.URL}, opts)
+	assert.NoError(t, err)
+	defer s.(*searxSearcher).Close()
+
+	// Bad queries are not the instance's fault so don't eject it
+	for i := 0; i < 5; i++ {
+		_, err := s.Query(context.Background(), &Query{Text: "boats", Lang: "en", PageNo: 1})
+		assert.Error(t, err)
+	}
+
+	stats := s.(StatsReporter).Stats()
+	assert.Equal(t, EndpointStats{Addr: server.URL, Healthy: true, Requests: 5}, stats[0].withoutLatency())
+}
+
+// withoutLatency is a helper function for comparing stats without the non-deterministic latency
+func (s EndpointStats) withoutLatency() EndpointStats {
+	s.AvgLatency = 0
+	return s
+}
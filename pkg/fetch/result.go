@@ -5,7 +5,7 @@
  * File Created: Sunday, 29th March 2020 5:26:58 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Sunday, 29th March 2020 5:27:21 pm
+ * Last Modified: Saturday, 17th October 2026 5:54:49 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package fetch
@@ -25,14 +25,21 @@ type Result struct {
 	ResultNo int `json:"resultno"`
 	// PageNo is the current pageNo of the response
 	PageNo int `json:"pageno"`
-	// Ready indicates if this Result is ready or not
-	Ready bool `json:"-"`
+	// QueryParams are the optional search parameters the results were queried with
+	QueryParams
 	// Any errors associated with this Result
 	Errors []error `json:"-"`
 
 	sync.Mutex
 }
 
+// addError is a helper function for safely appending an error to a Result
+func (r *Result) addError(err error) {
+	r.Lock()
+	r.Errors = append(r.Errors, err)
+	r.Unlock()
+}
+
 // HasErrors is a helper function to check if a Result contains errors
 func (r *Result) HasErrors() bool {
 	if len(r.Errors) > 0 {
@@ -0,0 +1,101 @@
+// Package fetch provides fetching utilities for various content types
+/*
+ * File: searcher.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:31:17 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:54:49 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	// BackendSearx is the identifier of the Searx metasearch backend
+	BackendSearx = "searx"
+	// BackendJSON is the identifier of the generic JSON-over-HTTP backend
+	BackendJSON = "json"
+	// BackendFile is the identifier of the local file/URL-list backend
+	BackendFile = "file"
+)
+
+// Searcher is an interface for the search backends a Fetcher can source content from.
+// Implementations translate a Query into backend specific requests and return
+// their results as SearxResult objects so the rest of the pipeline is unchanged.
+type Searcher interface {
+	// Name returns the backend identifier e.g. 'searx'
+	Name() string
+	// HealthCheck returns an error if the backend is unreachable
+	HealthCheck() error
+	// Query executes a query and returns the raw backend results.
+	// Implementations must abort the query once ctx is done.
+	Query(ctx context.Context, q *Query) ([]SearxResult, error)
+	// Normalize filters and post-processes raw results returned from Query
+	Normalize(results []SearxResult) []SearxResult
+}
+
+// Query is a struct for representing a single backend query
+type Query struct {
+	// Text is the search string
+	Text string
+	// Category is the content type to search for e.g. 'images'
+	Category string
+	// Lang is the language code to search in
+	Lang string
+	// PageNo is the page of results to retrieve
+	PageNo int
+	// QueryParams are the optional search parameters.
+	// Backends not implementing ParamValidator ignore them.
+	QueryParams
+}
+
+// NewSearcher is a function for initializing the Searcher implementation of the specified backend.
+// The addrs are interpreted by the backend i.e. server URLs for the 'searx' and 'json' backends
+// and a filepath for the 'file' backend. Only the 'searx' backend supports multiple addresses.
+// The mapping is only used by the 'json' backend and the opts are only used by the 'searx' backend.
+func NewSearcher(backend string, addrs []string, mapping *FieldMapping, opts *SearxOptions) (Searcher, error) {
+	backend = strings.ToLower(backend)
+	if backend == BackendSearx || backend == "" {
+		return NewSearxSearcher(addrs, opts)
+	}
+
+	if len(addrs) != 1 {
+		return nil, fmt.Errorf("the '%s' backend requires exactly one address; got %d", backend, len(addrs))
+	}
+
+	switch backend {
+	case BackendJSON:
+		return NewJSONSearcher(addrs[0], mapping)
+	case BackendFile:
+		return NewFileSearcher(addrs[0]), nil
+	}
+
+	return nil, fmt.Errorf("unrecognized search backend: '%s'", backend)
+}
+
+// normalizeResults is a helper function for the post processing shared by all backends.
+// Results without an image source are dropped and the sources are base64 encoded for
+// easier transport e.g. when piping output to JQ.
+func normalizeResults(results []SearxResult) []SearxResult {
+	var normalized = []SearxResult{}
+
+	for _, r := range results {
+		if r.ImgSrc == "" {
+			continue
+		}
+
+		r.ImgSrcB64 = base64.StdEncoding.EncodeToString([]byte(r.ImgSrc))
+		r.ThumbnailSrcB64 = base64.StdEncoding.EncodeToString([]byte(r.ThumbnailSrc))
+
+		normalized = append(normalized, r)
+	}
+
+	return normalized
+}
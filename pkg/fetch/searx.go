@@ -5,23 +5,234 @@
  * File Created: Saturday, 21st March 2020 9:01:55 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Thursday, 28th May 2020 9:52:07 pm
+ * Last Modified: Saturday, 17th October 2026 7:05:19 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package fetch
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
 )
 
+// SearxOptions is a struct for configuring the Searx client's timeouts, retries and rate limiting
+type SearxOptions struct {
+	// Timeout is the per-request timeout. 0 disables the timeout.
+	Timeout time.Duration
+	// Retry is the retry policy for transient failures i.e. network errors, 429 and 5xx responses
+	Retry throttle.Policy
+	// Rate is the maximum number of requests per second to each Searx endpoint. 0 disables rate limiting.
+	Rate float64
+	// Burst is the number of requests that may exceed the Rate in a short burst
+	Burst int
+
+	// FailureThreshold is the number of consecutive failures after which an endpoint is ejected. 0 disables ejection.
+	FailureThreshold int
+	// EjectDuration is the duration an unhealthy endpoint is ejected for
+	EjectDuration time.Duration
+	// HealthInterval is the interval between active endpoint health checks. 0 disables active health checks.
+	HealthInterval time.Duration
+}
+
+// DefaultSearxOptions returns the default Searx client options
+func DefaultSearxOptions() *SearxOptions {
+	return &SearxOptions{
+		Timeout: 30 * time.Second,
+		Retry: throttle.Policy{
+			MaxRetries: 3,
+			BaseDelay:  500 * time.Millisecond,
+			MaxDelay:   30 * time.Second,
+		},
+		Rate:             2,
+		Burst:            4,
+		FailureThreshold: 3,
+		EjectDuration:    30 * time.Second,
+		HealthInterval:   15 * time.Second,
+	}
+}
+
+// searxSearcher is the Searcher implementation for one or more Searx metasearch instances.
+// Queries are load balanced across the instances with health tracking.
+type searxSearcher struct {
+	opts   *SearxOptions
+	client *http.Client
+	pool   *endpointPool
+}
+
+// NewSearxSearcher is a function for initializing a Searcher connected to the Searx instances at addrs.
+// If opts is nil, the DefaultSearxOptions are used. The Searcher should be closed once no longer
+// in use to stop the endpoint health checks.
+func NewSearxSearcher(addrs []string, opts *SearxOptions) (Searcher, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no Searx endpoints configured")
+	}
+
+	if opts == nil {
+		opts = DefaultSearxOptions()
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+
+	return &searxSearcher{
+		opts:   opts,
+		client: client,
+		pool:   newEndpointPool(addrs, client, opts),
+	}, nil
+}
+
+// Name returns the backend identifier
+func (s *searxSearcher) Name() string {
+	return BackendSearx
+}
+
+// HealthCheck returns an error if none of the Searx instances are reachable.
+// Unreachable instances are ejected.
+func (s *searxSearcher) HealthCheck() error {
+	if s.pool.checkAll() == 0 {
+		var addrs []string
+		for _, ep := range s.pool.endpoints {
+			addrs = append(addrs, ep.addr)
+		}
+		return fmt.Errorf("unable to reach Searx at '%s'. Is a Searx server running at the specified address?", strings.Join(addrs, ", "))
+	}
+	return nil
+}
+
+// Stats returns the query statistics of each Searx instance
+func (s *searxSearcher) Stats() []EndpointStats {
+	return s.pool.stats()
+}
+
+// Close stops the endpoint health checks
+func (s *searxSearcher) Close() error {
+	s.pool.close()
+	return nil
+}
+
+// Query executes a query against the next healthy Searx instance.
+// Transient failures are retried according to the retry policy, each time on the next healthy instance.
+func (s *searxSearcher) Query(ctx context.Context, q *Query) ([]SearxResult, error) {
+	for attempt := 0; ; attempt++ {
+		ep := s.pool.pick()
+
+		if err := ep.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		results, err := searxQuery(ctx, s.client, ep.addr, q)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		retryable, retryAfter := isRetryable(err)
+		s.pool.record(ep, time.Since(start), isEndpointFailure(err))
+
+		if err == nil {
+			return results, nil
+		}
+
+		if !retryable || attempt >= s.opts.Retry.MaxRetries {
+			return nil, err
+		}
+
+		delay := s.opts.Retry.Delay(attempt, retryAfter)
+		log.Debugf("retrying Searx query: query=%s, lang=%s, endpoint=%s, attempt=%d, delay=%s, error=%s", q.Text, q.Lang, ep.addr, attempt+1, delay, err)
+
+		if err := throttle.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ValidateParams checks the query parameters against the engines advertised by the Searx '/config' endpoint.
+// Pinned engines must be enabled and support the category, safe search and time range.
+// If no engines are pinned, an error is only returned if no enabled engine of the category supports the time range.
+func (s *searxSearcher) ValidateParams(category string, params *QueryParams) error {
+	if params.IsZero() {
+		return nil
+	}
+
+	ep := s.pool.pick()
+	cfg, err := searxGetConfig(s.client, ep.addr)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve Searx config from '%s'; %s", ep.addr, err.Error())
+	}
+
+	return cfg.validate(category, params)
+}
+
+// Normalize drops results with unrecognized content types and applies the shared post processing
+func (s *searxSearcher) Normalize(results []SearxResult) []SearxResult {
+	var recognized = []SearxResult{}
+
+	for _, r := range results {
+		// Skip unrecognized content types
+		if r.ImgFmt == "" {
+			continue
+		}
+		recognized = append(recognized, r)
+	}
+
+	return normalizeResults(recognized)
+}
+
+// statusError is an error for representing an unexpected HTTP response status
+type statusError struct {
+	Code       int
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// isRetryable is a helper function for checking if a query error is transient.
+// Returns the server requested delay, if any.
+func isRetryable(err error) (bool, time.Duration) {
+	if err == nil {
+		return false, 0
+	}
+
+	if se, ok := err.(*statusError); ok {
+		return throttle.IsRetryableStatus(se.Code), se.RetryAfter
+	}
+
+	// Network errors including timeouts
+	_, ok := err.(net.Error)
+	return ok, 0
+}
+
+// isEndpointFailure is a helper function for checking if a query error counts against the health of the instance:
+// network errors, 429 and 5xx statuses. Other errors e.g. a 400 for unsupported parameters are not the instance's fault.
+func isEndpointFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if se, ok := err.(*statusError); ok {
+		return se.Code == http.StatusTooManyRequests || se.Code >= http.StatusInternalServerError
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
+
 // searxHealthCheck is a helper function to check connection status to the Searx server
-func searxHealthCheck(searxAddr string) bool {
-	resp, err := http.Get(searxAddr)
+func searxHealthCheck(client *http.Client, searxAddr string) bool {
+	resp, err := client.Get(searxAddr)
 	if err == nil {
+		defer resp.Body.Close()
 		if resp.StatusCode == http.StatusOK {
 			return true
 		}
@@ -31,8 +242,8 @@ func searxHealthCheck(searxAddr string) bool {
 }
 
 // searxQuery is a function for executing a Searx query
-func searxQuery(addr string, query string, category string, lang string, pageno int) ([]SearxResult, error) {
-	req, err := http.NewRequest("GET", addr, nil)
+func searxQuery(ctx context.Context, client *http.Client, addr string, query *Query) ([]SearxResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", addr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -40,19 +251,31 @@ func searxQuery(addr string, query string, category string, lang string, pageno
 	req.Header.Add("Accept", "application/json")
 
 	q := req.URL.Query()
-	q.Add("q", query)
-	q.Add("categories", category)
-	q.Add("language", lang)
+	q.Add("q", query.Text)
+	q.Add("categories", query.Category)
+	q.Add("language", query.Lang)
 	q.Add("format", "json")
-	q.Add("pageno", fmt.Sprintf("%d", pageno))
+	q.Add("pageno", fmt.Sprintf("%d", query.PageNo))
+	if len(query.Engines) > 0 {
+		q.Add("engines", strings.Join(query.Engines, ","))
+	}
+	if query.SafeSearch != "" {
+		q.Add("safesearch", fmt.Sprintf("%d", safeSearchLevels[query.SafeSearch]))
+	}
+	if query.TimeRange != "" {
+		q.Add("time_range", query.TimeRange)
+	}
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{}
-
 	rawResp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer rawResp.Body.Close()
+
+	if rawResp.StatusCode != http.StatusOK {
+		return nil, &statusError{Code: rawResp.StatusCode, RetryAfter: throttle.RetryAfter(rawResp)}
+	}
 
 	body, err := ioutil.ReadAll(rawResp.Body)
 	if err != nil {
@@ -67,7 +290,112 @@ func searxQuery(addr string, query string, category string, lang string, pageno
 	return fmtResponse.Results, nil
 }
 
-// SearxResult is a struct for representing the details of a Searx result
+// searxConfig is a struct for representing the engine details of a Searx '/config' response
+type searxConfig struct {
+	Engines []searxEngineConfig `json:"engines"`
+}
+
+// searxEngineConfig is a struct for representing the capabilities of a Searx engine
+type searxEngineConfig struct {
+	Name             string   `json:"name"`
+	Enabled          bool     `json:"enabled"`
+	Categories       []string `json:"categories"`
+	SafeSearch       bool     `json:"safesearch"`
+	TimeRangeSupport bool     `json:"time_range_support"`
+}
+
+// searxGetConfig is a function for retrieving the configuration advertised by a Searx instance
+func searxGetConfig(client *http.Client, addr string) (*searxConfig, error) {
+	resp, err := client.Get(strings.TrimSuffix(addr, "/") + "/config")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{Code: resp.StatusCode}
+	}
+
+	var cfg searxConfig
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validate is a method for checking query parameters against the advertised engines
+func (c *searxConfig) validate(category string, params *QueryParams) error {
+	engines := map[string]searxEngineConfig{}
+	for _, e := range c.Engines {
+		engines[strings.ToLower(e.Name)] = e
+	}
+
+	// The engines queried; all enabled engines of the category if none are pinned
+	var selected []searxEngineConfig
+	for _, name := range params.Engines {
+		e, ok := engines[name]
+		switch {
+		case !ok:
+			return fmt.Errorf("engine '%s' is not available on the Searx server", name)
+		case !e.Enabled:
+			return fmt.Errorf("engine '%s' is disabled on the Searx server", name)
+		case !contains(e.Categories, category):
+			return fmt.Errorf("engine '%s' does not support the '%s' category", name, category)
+		}
+		selected = append(selected, e)
+	}
+
+	pinned := len(selected) > 0
+	if !pinned {
+		for _, e := range c.Engines {
+			if e.Enabled && contains(e.Categories, category) {
+				selected = append(selected, e)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return fmt.Errorf("no engines enabled for the '%s' category on the Searx server", category)
+	}
+
+	if params.TimeRange != "" {
+		var unsupported []string
+		for _, e := range selected {
+			if !e.TimeRangeSupport {
+				unsupported = append(unsupported, e.Name)
+			}
+		}
+
+		if len(unsupported) == len(selected) || (pinned && len(unsupported) > 0) {
+			return fmt.Errorf("time range is not supported by engines: %s", strings.Join(unsupported, ", "))
+		}
+		if len(unsupported) > 0 {
+			log.Warnf("time range is not supported and will be ignored by engines: %s", strings.Join(unsupported, ", "))
+		}
+	}
+
+	if params.SafeSearch != "" && params.SafeSearch != SafeSearchOff {
+		var unsupported []string
+		for _, e := range selected {
+			if !e.SafeSearch {
+				unsupported = append(unsupported, e.Name)
+			}
+		}
+
+		if pinned && len(unsupported) > 0 {
+			return fmt.Errorf("safe search is not supported by engines: %s", strings.Join(unsupported, ", "))
+		}
+		if len(unsupported) > 0 {
+			log.Warnf("safe search is not supported by engines: %s; results from these engines are unfiltered", strings.Join(unsupported, ", "))
+		}
+	}
+
+	return nil
+}
+
+// SearxResult is a struct for representing the details of a Searx result.
+// All Searcher backends normalize their results to this representation.
 type SearxResult struct {
 	URL             string `json:"url"`
 	ImgFmt          string `json:"img_format"`
@@ -78,6 +406,7 @@ type SearxResult struct {
 	Engine          string `json:"engine"`
 	Source          string `json:"source"`
 	Title           string `json:"title"`
+	Resolution      string `json:"resolution"`
 }
 
 // searxResponse is a struct for representing a Searx response
@@ -88,7 +417,6 @@ type searxResponse struct {
 // formatSearxResponse is a function for unmarshalling a Searx query into a response object
 func formatSearxResponse(searxJSONResponse []byte) (*searxResponse, error) {
 	var resp searxResponse
-	var results = []SearxResult{}
 
 	// Unmarshal original response
 	err := json.Unmarshal(searxJSONResponse, &resp)
@@ -96,24 +424,5 @@ func formatSearxResponse(searxJSONResponse []byte) (*searxResponse, error) {
 		return nil, err
 	}
 
-	// Post processing of the results
-	for i := range resp.Results {
-		// Skip unrecognized content types
-		if resp.Results[i].ImgFmt == "" {
-			continue
-		}
-
-		// Base64 encode for easier transport e.g. when piping output to JQ
-		imgSrcB64 := base64.StdEncoding.EncodeToString([]byte(resp.Results[i].ImgSrc))
-		thumbSrcB64 := base64.StdEncoding.EncodeToString([]byte(resp.Results[i].ThumbnailSrc))
-		resp.Results[i].ImgSrcB64 = imgSrcB64
-		resp.Results[i].ThumbnailSrcB64 = thumbSrcB64
-
-		results = append(results, resp.Results[i])
-	}
-
-	// Update response results
-	resp.Results = results
-
 	return &resp, nil
 }
@@ -0,0 +1,178 @@
+/*
+ * File: searx_test.go
+ * Project: fetch
+ * File Created: Saturday, 17th October 2026 5:41:22 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:54:49 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/throttle"
+)
+
+const searxTestResponse = `{"results": [{"url": "http://a.com", "img_src": "http://a.com/1.jpg", "img_format": "jpeg"}]}`
+
+func TestSearxQueryRetry(t *testing.T) {
+	opts := &SearxOptions{
+		Timeout: time.Second,
+		Retry:   throttle.Policy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}
+
+	tests := map[string]struct {
+		statuses []int
+		calls    int32
+		err      bool
+	}{
+		"Success":         {[]int{200}, 1, false},
+		"Retry 429":       {[]int{429, 200}, 2, false},
+		"Retry 5xx":       {[]int{503, 502, 200}, 3, false},
+		"Retries Exhaust": {[]int{500, 500, 500, 200}, 3, true},
+		"Not Retryable":   {[]int{404, 200}, 1, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			i := atomic.AddInt32(&calls, 1) - 1
+			if status := test.statuses[i]; status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Write([]byte(searxTestResponse))
+		}))
+
+		s, _ := NewSearxSearcher([]string{server.URL}, opts)
+		results, err := s.Query(context.Background(), &Query{Text: "boats", Category: "images", Lang: "en", PageNo: 1})
+
+		assert.Equal(t, test.calls, atomic.LoadInt32(&calls))
+		if test.err {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Len(t, results, 1)
+		}
+
+		server.Close()
+	}
+}
+
+const searxTestConfig = `{"engines": [
+	{"name": "bing images", "enabled": true, "categories": ["images"], "safesearch": true, "time_range_support": true},
+	{"name": "flickr", "enabled": true, "categories": ["images"], "safesearch": false, "time_range_support": false},
+	{"name": "duckduckgo", "enabled": true, "categories": ["general"], "safesearch": true, "time_range_support": true},
+	{"name": "google images", "enabled": false, "categories": ["images"], "safesearch": true, "time_range_support": true}
+]}`
+
+func TestSearxQueryParams(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(searxTestResponse))
+	}))
+	defer server.Close()
+
+	s, _ := NewSearxSearcher([]string{server.URL}, &SearxOptions{Timeout: time.Second})
+	params, err := NewQueryParams([]string{"Bing Images", " flickr"}, "2", "week")
+	assert.NoError(t, err)
+
+	_, err = s.Query(context.Background(), &Query{Text: "boats", Category: "images", Lang: "en", PageNo: 1, QueryParams: *params})
+	assert.NoError(t, err)
+	assert.Equal(t, "bing images,flickr", query.Get("engines"))
+	assert.Equal(t, "2", query.Get("safesearch"))
+	assert.Equal(t, "week", query.Get("time_range"))
+
+	// Unset parameters are left to the server defaults
+	_, err = s.Query(context.Background(), &Query{Text: "boats", Category: "images", Lang: "en", PageNo: 1})
+	assert.NoError(t, err)
+	for _, key := range []string{"engines", "safesearch", "time_range"} {
+		_, ok := query[key]
+		assert.False(t, ok, key)
+	}
+}
+
+func TestSearxValidateParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(searxTestConfig))
+	}))
+	defer server.Close()
+
+	s, _ := NewSearxSearcher([]string{server.URL + "/"}, &SearxOptions{Timeout: time.Second})
+	validator := s.(ParamValidator)
+
+	tests := map[string]struct {
+		params QueryParams
+		err    bool
+	}{
+		"No Params":                 {QueryParams{}, false},
+		"Pinned Engine":             {QueryParams{Engines: []string{"bing images"}, SafeSearch: SafeSearchStrict, TimeRange: "day"}, false},
+		"Unknown Engine":            {QueryParams{Engines: []string{"yandex images"}}, true},
+		"Disabled Engine":           {QueryParams{Engines: []string{"google images"}}, true},
+		"Wrong Category":            {QueryParams{Engines: []string{"duckduckgo"}}, true},
+		"Pinned Without Time Range": {QueryParams{Engines: []string{"flickr"}, TimeRange: "year"}, true},
+		"Pinned Without Safesearch": {QueryParams{Engines: []string{"flickr"}, SafeSearch: SafeSearchModerate}, true},
+		"Safesearch Off":            {QueryParams{Engines: []string{"flickr"}, SafeSearch: SafeSearchOff}, false},
+		"Partial Time Range":        {QueryParams{TimeRange: "month"}, false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		err := validator.ValidateParams("images", &test.params)
+		if test.err {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	// No image engine supports the time range once the supporting engine is excluded
+	cfg := &searxConfig{Engines: []searxEngineConfig{{Name: "flickr", Enabled: true, Categories: []string{"images"}}}}
+	assert.Error(t, cfg.validate("images", &QueryParams{TimeRange: "day"}))
+}
+
+func TestNewQueryParams(t *testing.T) {
+	tests := map[string]struct {
+		safeSearch string
+		timeRange  string
+		expected   *QueryParams
+		err        bool
+	}{
+		"Empty":              {"", "", &QueryParams{}, false},
+		"Named Level":        {"Moderate", "", &QueryParams{SafeSearch: SafeSearchModerate}, false},
+		"Numeric Level":      {"0", "YEAR", &QueryParams{SafeSearch: SafeSearchOff, TimeRange: "year"}, false},
+		"Invalid Level":      {"3", "", nil, true},
+		"Invalid Time Range": {"", "decade", nil, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		params, err := NewQueryParams(nil, test.safeSearch, test.timeRange)
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, params)
+	}
+}
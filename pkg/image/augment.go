@@ -0,0 +1,250 @@
+// Package image provides image processing utilities
+/*
+ * File: augment.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:42:07 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:57:36 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// AugmentOptions is a struct for declaring the ranges the transforms of variants are randomly drawn from.
+// Zero values disable a transform.
+type AugmentOptions struct {
+	// FlipH is the probability of mirroring a variant left to right
+	FlipH float64
+	// FlipV is the probability of mirroring a variant top to bottom
+	FlipV float64
+	// Rotate90 rotates variants by a random multiple of 90 degrees
+	Rotate90 bool
+	// Rotate is the maximum angle in degrees of a random rotation either way
+	Rotate float64
+	// Crop is the minimum fraction of each side kept by a random crop, in (0, 1]; 1 keeps the whole image
+	Crop float64
+	// Brightness is the maximum percentage change in brightness either way, up to 100
+	Brightness float64
+	// Contrast is the maximum percentage change in contrast either way, up to 100
+	Contrast float64
+	// Saturation is the maximum percentage change in saturation either way, up to 100
+	Saturation float64
+	// Blur is the maximum sigma of a Gaussian blur
+	Blur float64
+	// Noise is the maximum standard deviation of the Gaussian noise added to each color channel (0-255)
+	Noise float64
+}
+
+// Validate is a method for checking the augment options are within their ranges
+func (o *AugmentOptions) Validate() error {
+	for name, p := range map[string]float64{"flip-h": o.FlipH, "flip-v": o.FlipV} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("invalid %s probability %g; must be between 0 and 1", name, p)
+		}
+	}
+	if o.Crop < 0 || o.Crop > 1 {
+		return fmt.Errorf("invalid crop fraction %g; must be between 0 and 1", o.Crop)
+	}
+	for name, pct := range map[string]float64{"brightness": o.Brightness, "contrast": o.Contrast, "saturation": o.Saturation} {
+		if pct < 0 || pct > 100 {
+			return fmt.Errorf("invalid %s %g; must be between 0 and 100", name, pct)
+		}
+	}
+	for name, v := range map[string]float64{"rotate": o.Rotate, "blur": o.Blur, "noise": o.Noise} {
+		if v < 0 {
+			return fmt.Errorf("invalid %s %g; must not be negative", name, v)
+		}
+	}
+	return nil
+}
+
+// CropRect is a struct for representing a crop of an image in pixels
+type CropRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Augmentation is an Operation for representing the transforms of a single variant, applied in the order
+// crop, flips, rotation, color jitter, blur and noise. Rotations other than multiples of 90 degrees keep
+// the size of the image, filling the uncovered corners with black.
+type Augmentation struct {
+	Crop       *CropRect `json:"crop,omitempty"`
+	FlipH      bool      `json:"flip_h,omitempty"`
+	FlipV      bool      `json:"flip_v,omitempty"`
+	Rotate90   int       `json:"rotate_90,omitempty"`
+	Rotate     float64   `json:"rotate,omitempty"`
+	Brightness float64   `json:"brightness,omitempty"`
+	Contrast   float64   `json:"contrast,omitempty"`
+	Saturation float64   `json:"saturation,omitempty"`
+	Blur       float64   `json:"blur,omitempty"`
+	Noise      float64   `json:"noise,omitempty"`
+	NoiseSeed  int64     `json:"noise_seed,omitempty"`
+}
+
+// AugmentSeed is a function for deriving the seed of a variant from a run seed, the key of the image
+// e.g. its path and the variant index, so variants are reproducible regardless of processing order
+func AugmentSeed(seed int64, key string, index int) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%d", seed, key, index)
+	return int64(h.Sum64())
+}
+
+// RandomAugmentation is a function for drawing the transforms of a variant of a width x height image
+func RandomAugmentation(rng *rand.Rand, opts *AugmentOptions, width, height int) *Augmentation {
+	a := &Augmentation{}
+
+	if opts.Crop > 0 && opts.Crop < 1 {
+		scale := opts.Crop + rng.Float64()*(1-opts.Crop)
+		w := int(math.Max(1, math.Round(float64(width)*scale)))
+		h := int(math.Max(1, math.Round(float64(height)*scale)))
+		a.Crop = &CropRect{X: rng.Intn(width - w + 1), Y: rng.Intn(height - h + 1), Width: w, Height: h}
+	}
+
+	a.FlipH = rng.Float64() < opts.FlipH
+	a.FlipV = rng.Float64() < opts.FlipV
+	if opts.Rotate90 {
+		a.Rotate90 = rng.Intn(4) * 90
+	}
+	a.Rotate = uniform(rng, opts.Rotate)
+
+	a.Brightness = uniform(rng, opts.Brightness)
+	a.Contrast = uniform(rng, opts.Contrast)
+	a.Saturation = uniform(rng, opts.Saturation)
+
+	a.Blur = rng.Float64() * opts.Blur
+	if opts.Noise > 0 {
+		a.Noise = rng.Float64() * opts.Noise
+		a.NoiseSeed = rng.Int63()
+	}
+
+	return a
+}
+
+// uniform is a helper function for drawing a value uniformly from [-max, max], or 0 if max is 0
+func uniform(rng *rand.Rand, max float64) float64 {
+	if max == 0 {
+		return 0
+	}
+	return (rng.Float64()*2 - 1) * max
+}
+
+// Name returns the operation name
+func (a *Augmentation) Name() string { return "augment" }
+
+// Apply transforms the frame image
+func (a *Augmentation) Apply(f *Frame) error {
+	img := f.Image
+
+	if a.Crop != nil {
+		b := img.Bounds()
+		rect := image.Rect(a.Crop.X, a.Crop.Y, a.Crop.X+a.Crop.Width, a.Crop.Y+a.Crop.Height).Add(b.Min)
+		if !rect.In(b) || rect.Empty() {
+			return fmt.Errorf("crop %+v is outside the %dx%d image", *a.Crop, b.Dx(), b.Dy())
+		}
+		img = imaging.Crop(img, rect)
+	}
+
+	if a.FlipH {
+		img = imaging.FlipH(img)
+	}
+	if a.FlipV {
+		img = imaging.FlipV(img)
+	}
+	if a.Rotate90 != 0 {
+		img = imaging.Rotate(img, float64(a.Rotate90), color.Black)
+	}
+	if a.Rotate != 0 {
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		img = imaging.CropCenter(imaging.Rotate(img, a.Rotate, color.Black), w, h)
+	}
+
+	if a.Brightness != 0 {
+		img = imaging.AdjustBrightness(img, a.Brightness)
+	}
+	if a.Contrast != 0 {
+		img = imaging.AdjustContrast(img, a.Contrast)
+	}
+	if a.Saturation != 0 {
+		img = imaging.AdjustSaturation(img, a.Saturation)
+	}
+
+	if a.Blur > 0 {
+		img = imaging.Blur(img, a.Blur)
+	}
+	if a.Noise > 0 {
+		img = addNoise(img, a.Noise, a.NoiseSeed)
+	}
+
+	f.Image = img
+	return nil
+}
+
+// addNoise is a helper function for adding Gaussian noise with the specified standard deviation to the
+// color channels of an image
+func addNoise(img image.Image, stddev float64, seed int64) *image.NRGBA {
+	dst := imaging.Clone(img)
+	rng := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			v := float64(dst.Pix[i+c]) + rng.NormFloat64()*stddev
+			dst.Pix[i+c] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+		}
+	}
+
+	return dst
+}
+
+// AugmentImage is a function for generating a variant of an image per Augmentation, decoding the image once.
+// Variants are encoded to the content type if not empty, otherwise the image's format or PNG if it can only be
+// decoded e.g. WebP, with the specified options (nil for the defaults). Returns the variants and their content type.
+func AugmentImage(ctx context.Context, imgBytes []byte, augs []*Augmentation, contentType string, opts *EncodeOptions) ([][]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if contentType == "" {
+		contentType = outputContentType(DetectContentType(imgBytes))
+	}
+	f, ok := LookupFormat(contentType)
+	if !ok || !f.CanEncode() {
+		return nil, "", fmt.Errorf("unable to encode variants as '%s'; must be one of %s", contentType, strings.Join(EncodableTypes(), ", "))
+	}
+
+	variants := make([][]byte, 0, len(augs))
+	for _, a := range augs {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		frame := &Frame{Image: img, ContentType: f.ContentType}
+		if err := a.Apply(frame); err != nil {
+			return nil, "", err
+		}
+
+		encoded, err := encodeFormat(frame.Image, frame.ContentType, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		variants = append(variants, encoded)
+	}
+
+	return variants, f.ContentType, nil
+}
@@ -0,0 +1,138 @@
+/*
+ * File: augment_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:42:27 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:57:36 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestRandomAugmentation(t *testing.T) {
+	opts := &AugmentOptions{
+		FlipH:      0.5,
+		Rotate90:   true,
+		Rotate:     15,
+		Crop:       0.8,
+		Brightness: 20,
+		Blur:       2,
+		Noise:      10,
+	}
+
+	draw := func(seed int64, key string, index int) *Augmentation {
+		return RandomAugmentation(rand.New(rand.NewSource(AugmentSeed(seed, key, index))), opts, 400, 200)
+	}
+
+	// Variants are reproducible from the seed, key and index
+	assert.Equal(t, draw(1, "a.png", 0), draw(1, "a.png", 0))
+	assert.NotEqual(t, draw(1, "a.png", 0), draw(1, "a.png", 1))
+	assert.NotEqual(t, draw(1, "a.png", 0), draw(1, "b.png", 0))
+	assert.NotEqual(t, draw(1, "a.png", 0), draw(2, "a.png", 0))
+
+	for i := 0; i < 100; i++ {
+		a := draw(1, "a.png", i)
+
+		if assert.NotNil(t, a.Crop) {
+			assert.GreaterOrEqual(t, a.Crop.Width, 320)
+			assert.GreaterOrEqual(t, a.Crop.Height, 160)
+			assert.LessOrEqual(t, a.Crop.X+a.Crop.Width, 400)
+			assert.LessOrEqual(t, a.Crop.Y+a.Crop.Height, 200)
+		}
+		assert.False(t, a.FlipV)
+		assert.Contains(t, []int{0, 90, 180, 270}, a.Rotate90)
+		assert.InDelta(t, 0, a.Rotate, 15)
+		assert.InDelta(t, 0, a.Brightness, 20)
+		assert.Zero(t, a.Contrast)
+		assert.Zero(t, a.Saturation)
+		assert.InDelta(t, 1, a.Blur, 1)
+		assert.InDelta(t, 5, a.Noise, 5)
+	}
+}
+
+func TestAugmentImage(t *testing.T) {
+	pngImg, _ := test_utils.NewPatternImage("image/png", 400, 200, 1)
+
+	tests := map[string]struct {
+		aug         *Augmentation
+		contentType string
+		width       int
+		height      int
+		err         bool
+	}{
+		"Identity":     {&Augmentation{}, "", 400, 200, false},
+		"Crop":         {&Augmentation{Crop: &CropRect{X: 10, Y: 20, Width: 100, Height: 50}}, "", 100, 50, false},
+		"Rotate 90":    {&Augmentation{Rotate90: 90, FlipH: true}, "", 200, 400, false},
+		"Rotate":       {&Augmentation{Rotate: 12.5}, "", 400, 200, false},
+		"Jitter":       {&Augmentation{Brightness: 10, Contrast: -10, Saturation: 20, Blur: 1, Noise: 5, NoiseSeed: 3}, "image/jpeg", 400, 200, false},
+		"Invalid Crop": {&Augmentation{Crop: &CropRect{X: 350, Y: 0, Width: 100, Height: 50}}, "", 0, 0, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		variants, contentType, err := AugmentImage(context.Background(), pngImg, []*Augmentation{test.aug, test.aug}, test.contentType, nil)
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+		if !assert.NoError(t, err) || !assert.Len(t, variants, 2) {
+			continue
+		}
+
+		// Applying the same augmentation gives the same variant
+		assert.True(t, bytes.Equal(variants[0], variants[1]))
+		if test.contentType != "" {
+			assert.Equal(t, test.contentType, contentType)
+		} else {
+			assert.Equal(t, ContentTypePNG, contentType)
+		}
+
+		stats, err := GetStats(variants[0])
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.width, stats.Width)
+			assert.Equal(t, test.height, stats.Height)
+		}
+	}
+
+	// WebP can't be encoded, so WebP images are augmented as PNG by default
+	_, _, err := AugmentImage(context.Background(), pngImg, []*Augmentation{{}}, ContentTypeWebP, nil)
+	assert.Error(t, err)
+
+	variants, contentType, err := AugmentImage(context.Background(), test_utils.NewWebPImage(), []*Augmentation{{FlipH: true}}, "", nil)
+	if assert.NoError(t, err) && assert.Len(t, variants, 1) {
+		assert.Equal(t, ContentTypePNG, contentType)
+		assert.Equal(t, ContentTypePNG, DetectContentType(variants[0]))
+	}
+}
+
+func TestAugmentOptionsValidate(t *testing.T) {
+	tests := map[string]struct {
+		opts *AugmentOptions
+		err  bool
+	}{
+		"Zero":                {&AugmentOptions{}, false},
+		"Valid":               {&AugmentOptions{FlipH: 0.5, Crop: 0.8, Brightness: 20, Rotate: 10, Noise: 5}, false},
+		"Invalid Probability": {&AugmentOptions{FlipV: 1.5}, true},
+		"Invalid Crop":        {&AugmentOptions{Crop: 2}, true},
+		"Invalid Percentage":  {&AugmentOptions{Contrast: 150}, true},
+		"Negative Blur":       {&AugmentOptions{Blur: -1}, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		err := test.opts.Validate()
+		assert.Equal(t, test.err, err != nil, "error=%v", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package image provides image processing utilities
+/*
+ * File: dedupe.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 5:38:56 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:04:47 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeepPolicy is a type for representing how the member to keep is chosen from a duplicate cluster
+type KeepPolicy int
+
+const (
+	// KeepResolution keeps the member with the largest pixel count
+	KeepResolution KeepPolicy = iota
+	// KeepFileSize keeps the member with the largest file size
+	KeepFileSize
+	// KeepFirst keeps the member first in the input order (see DedupeEntry.Seq)
+	KeepFirst
+)
+
+// String returns the name of the policy
+func (p KeepPolicy) String() string {
+	switch p {
+	case KeepResolution:
+		return "resolution"
+	case KeepFileSize:
+		return "filesize"
+	case KeepFirst:
+		return "first"
+	}
+	return fmt.Sprintf("KeepPolicy(%d)", int(p))
+}
+
+// ParseKeepPolicy is a function for parsing a keep policy name e.g. 'resolution'
+func ParseKeepPolicy(s string) (KeepPolicy, error) {
+	switch strings.ToLower(s) {
+	case "resolution":
+		return KeepResolution, nil
+	case "filesize":
+		return KeepFileSize, nil
+	case "first":
+		return KeepFirst, nil
+	}
+	return 0, fmt.Errorf("unrecognized keep policy '%s'", s)
+}
+
+// DedupeEntry is a struct for representing an image tracked by a Deduper
+type DedupeEntry struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	MD5    string `json:"md5"`
+	Hash   string `json:"hash"`
+	// Seq is the position of the image in the input, deciding ties and KeepFirst independently of the order
+	// images are added in
+	Seq int `json:"-"`
+
+	hash uint64
+}
+
+// Cluster is a struct for representing a group of duplicate images
+type Cluster struct {
+	// Keep is the member chosen by the keep policy
+	Keep *DedupeEntry `json:"keep"`
+	// Duplicates are the remaining members
+	Duplicates []*DedupeEntry `json:"duplicates"`
+	// Exact indicates all members are byte-for-byte identical
+	Exact bool `json:"exact"`
+}
+
+// Deduper is a struct for grouping exact and near-duplicate images into clusters.
+// Exact duplicates are detected using the MD5 checksum and near-duplicates using a
+// perceptual hash index. Clusters are not transitive: an image only joins a cluster if it is
+// within the threshold of every member, so if A matches B and B matches C but A does not
+// match C, C is not clustered with A.
+//
+// Deduper is not safe for concurrent use.
+type Deduper struct {
+	// Threshold is the maximum Hamming distance at which two images are considered near-duplicates.
+	// A negative threshold disables near-duplicate detection.
+	Threshold int
+	// Policy selects the member to keep from each cluster
+	Policy KeepPolicy
+
+	kind    HashKind
+	index   *HashIndex
+	exact   map[string]int
+	entries []*DedupeEntry
+	// cluster is the cluster of each entry
+	cluster []int
+	// members are the entries of each cluster
+	members [][]int
+	// best is the kept entry of each cluster
+	best []int
+}
+
+// NewDeduper is a function for initializing a new Deduper
+func NewDeduper(kind HashKind, threshold int, policy KeepPolicy) *Deduper {
+	return &Deduper{
+		Threshold: threshold,
+		Policy:    policy,
+		kind:      kind,
+		index:     NewHashIndex(),
+		exact:     make(map[string]int),
+	}
+}
+
+// NewDedupeEntry is a function for computing the checksums and dimensions of an encoded image
+func NewDedupeEntry(path string, imgBytes []byte, kind HashKind) (*DedupeEntry, error) {
+	md5, err := Hash(imgBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := HashImage(img, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DedupeEntry{
+		Path:   path,
+		Size:   len(imgBytes),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		MD5:    md5,
+		Hash:   fmt.Sprintf("%016x", hash),
+		hash:   hash,
+	}, nil
+}
+
+// Add adds an image to the Deduper and returns the entries that became duplicates as a result.
+// The returned entries are either the added entry itself, or the previously kept entry of the
+// cluster it joined that was displaced by the added entry according to the keep policy.
+// Exact duplicates join the cluster of their first copy; near-duplicates join the cluster with the
+// nearest kept member among those whose every member is within the threshold.
+func (d *Deduper) Add(e *DedupeEntry) []*DedupeEntry {
+	i := len(d.entries)
+	d.entries = append(d.entries, e)
+
+	target := -1
+	if j, ok := d.exact[e.MD5]; ok {
+		target = d.cluster[j]
+	} else {
+		d.exact[e.MD5] = i
+	}
+
+	if d.Threshold >= 0 {
+		if target < 0 {
+			// Count the members of each cluster within the threshold
+			hits := map[int]int{}
+			keepDist := map[int]int{}
+			for _, m := range d.index.Search(e.hash, d.Threshold) {
+				j, _ := strconv.Atoi(m.ID)
+				c := d.cluster[j]
+				hits[c]++
+				if d.best[c] == j {
+					keepDist[c] = m.Distance
+				}
+			}
+
+			for c, n := range hits {
+				if n < len(d.members[c]) {
+					continue
+				}
+				if target < 0 || keepDist[c] < keepDist[target] || (keepDist[c] == keepDist[target] && c < target) {
+					target = c
+				}
+			}
+		}
+		d.index.Add(e.hash, strconv.Itoa(i))
+	}
+
+	if target < 0 {
+		d.cluster = append(d.cluster, len(d.members))
+		d.members = append(d.members, []int{i})
+		d.best = append(d.best, i)
+		return nil
+	}
+
+	d.cluster = append(d.cluster, target)
+	d.members[target] = append(d.members[target], i)
+
+	// The previously kept member is displaced if the added entry is better
+	displaced := i
+	if d.better(i, d.best[target]) {
+		displaced, d.best[target] = d.best[target], i
+	}
+
+	return []*DedupeEntry{d.entries[displaced]}
+}
+
+// Clusters returns all clusters with more than one member, ordered by the path of the kept member
+func (d *Deduper) Clusters() []*Cluster {
+	clusters := []*Cluster{}
+	for c, m := range d.members {
+		if len(m) < 2 {
+			continue
+		}
+
+		keep := d.best[c]
+		c := &Cluster{Keep: d.entries[keep], Duplicates: []*DedupeEntry{}, Exact: true}
+		for _, i := range m {
+			if i == keep {
+				continue
+			}
+			c.Duplicates = append(c.Duplicates, d.entries[i])
+			c.Exact = c.Exact && d.entries[i].MD5 == c.Keep.MD5
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Keep.Path < clusters[j].Keep.Path
+	})
+
+	return clusters
+}
+
+// Len returns the number of images added
+func (d *Deduper) Len() int {
+	return len(d.entries)
+}
+
+// better reports whether entry i should be kept over entry j according to the keep policy.
+// Ties are broken by the remaining criteria, then by the input order and finally by the order the
+// entries were added.
+func (d *Deduper) better(i, j int) bool {
+	a, b := d.entries[i], d.entries[j]
+
+	resolution := func() int { return a.Width*a.Height - b.Width*b.Height }
+	size := func() int { return a.Size - b.Size }
+
+	var criteria []func() int
+	switch d.Policy {
+	case KeepResolution:
+		criteria = []func() int{resolution, size}
+	case KeepFileSize:
+		criteria = []func() int{size, resolution}
+	}
+
+	for _, c := range criteria {
+		if diff := c(); diff != 0 {
+			return diff > 0
+		}
+	}
+
+	if a.Seq != b.Seq {
+		return a.Seq < b.Seq
+	}
+	return i < j
+}
@@ -0,0 +1,132 @@
+/*
+ * File: dedupe_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 5:39:40 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:04:47 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestDeduper(t *testing.T) {
+	small, _ := test_utils.NewPatternImage("image/jpeg", 200, 150, 1)
+	large, _ := test_utils.NewPatternImage("image/png", 400, 300, 1)
+	other, _ := test_utils.NewPatternImage("image/png", 400, 300, 2)
+
+	tests := map[string]struct {
+		policy    KeepPolicy
+		inputs    map[string][]byte
+		order     []string
+		keep      string
+		displaced []string
+	}{
+		"Keep Resolution": {
+			KeepResolution,
+			map[string][]byte{"small": small, "large": large, "copy": small, "other": other},
+			[]string{"small", "copy", "other", "large"},
+			"large",
+			[]string{"", "copy", "", "small"},
+		},
+		"Keep First": {
+			KeepFirst,
+			map[string][]byte{"small": small, "large": large, "other": other},
+			[]string{"small", "other", "large"},
+			"small",
+			[]string{"", "", "large"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		d := NewDeduper(PHash, DefaultHashThreshold, test.policy)
+		for i, p := range test.order {
+			e, err := NewDedupeEntry(p, test.inputs[p], PHash)
+			assert.NoError(t, err)
+
+			displaced := d.Add(e)
+			if test.displaced[i] == "" {
+				assert.Empty(t, displaced)
+			} else if assert.Len(t, displaced, 1) {
+				assert.Equal(t, test.displaced[i], displaced[0].Path)
+			}
+		}
+
+		clusters := d.Clusters()
+		if assert.Len(t, clusters, 1) {
+			assert.Equal(t, test.keep, clusters[0].Keep.Path)
+			assert.False(t, clusters[0].Exact)
+		}
+	}
+}
+
+func TestDeduperExactOnly(t *testing.T) {
+	small, _ := test_utils.NewPatternImage("image/jpeg", 200, 150, 1)
+	large, _ := test_utils.NewPatternImage("image/png", 400, 300, 1)
+
+	d := NewDeduper(PHash, -1, KeepFileSize)
+	for _, p := range []string{"a", "b"} {
+		e, _ := NewDedupeEntry(p, small, PHash)
+		d.Add(e)
+	}
+	e, _ := NewDedupeEntry("c", large, PHash)
+	assert.Empty(t, d.Add(e))
+
+	clusters := d.Clusters()
+	if assert.Len(t, clusters, 1) {
+		assert.True(t, clusters[0].Exact)
+		assert.Equal(t, "a", clusters[0].Keep.Path)
+		assert.Len(t, clusters[0].Duplicates, 1)
+	}
+}
+
+func TestDeduperNotTransitive(t *testing.T) {
+	// b is within the threshold of a and c, but a and c are not
+	entries := []*DedupeEntry{
+		{Path: "a", MD5: "a", hash: 0x0},
+		{Path: "b", MD5: "b", hash: 0x7},
+		{Path: "c", MD5: "c", hash: 0x3f},
+		{Path: "d", MD5: "d", hash: 0x3},
+	}
+
+	d := NewDeduper(PHash, 4, KeepFirst)
+	assert.Empty(t, d.Add(entries[0]))
+	assert.Equal(t, []*DedupeEntry{entries[1]}, d.Add(entries[1]))
+	assert.Empty(t, d.Add(entries[2]))
+	assert.Equal(t, []*DedupeEntry{entries[3]}, d.Add(entries[3]))
+
+	clusters := d.Clusters()
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, "a", clusters[0].Keep.Path)
+		assert.Equal(t, []*DedupeEntry{entries[1], entries[3]}, clusters[0].Duplicates)
+	}
+}
+
+func TestDeduperSeq(t *testing.T) {
+	img, _ := test_utils.NewPatternImage("image/png", 200, 150, 1)
+
+	// Images added out of input order keep the first in the input
+	d := NewDeduper(PHash, DefaultHashThreshold, KeepFirst)
+	for i, p := range []string{"c", "a", "b"} {
+		e, err := NewDedupeEntry(p, img, PHash)
+		if !assert.NoError(t, err) {
+			return
+		}
+		e.Seq = []int{2, 0, 1}[i]
+		d.Add(e)
+	}
+
+	clusters := d.Clusters()
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, "a", clusters[0].Keep.Path)
+		assert.True(t, clusters[0].Exact)
+	}
+}
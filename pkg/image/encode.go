@@ -5,7 +5,7 @@
  * File Created: Saturday, 4th April 2020 9:46:49 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Friday, 26th March 2021 5:35:51 pm
+ * Last Modified: Saturday, 17th October 2026 6:30:47 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
@@ -14,82 +14,151 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
 	"image/png"
-	"net/http"
 	"path"
+	"strconv"
 	"strings"
 )
 
-const (
-	// ContentTypeJPEG is the 'image/jpeg' MIME type
-	ContentTypeJPEG = "image/jpeg"
-	// ContentTypePNG is the 'image/png' MIME type
-	ContentTypePNG = "image/png"
-)
+// EncodeOptions is a struct for representing the settings images are encoded with.
+// The zero value holds the encoders' defaults.
+//
+// Chroma subsampling is not configurable: the standard library JPEG encoder always subsamples
+// color images 4:2:0.
+type EncodeOptions struct {
+	// JPEGQuality is the JPEG quality from 1 to 100; 0 for the default of 75
+	JPEGQuality int
+	// PNGCompression is the PNG compression level; the zero value is the default level
+	PNGCompression png.CompressionLevel
+	// Background is the color transparent areas are flattened onto when encoding to a format
+	// without transparency e.g. JPEG; nil for white
+	Background color.Color
+}
 
-// checkSupportedContentType is a helper function for checking supported content types for conversion
-func checkSupportedContentType(contentType string) bool {
-	switch contentType {
-	case ContentTypeJPEG, ContentTypePNG:
-		return true
+// jpegQuality returns the JPEG quality, defaulting to jpeg.DefaultQuality
+func (o *EncodeOptions) jpegQuality() int {
+	if o == nil || o.JPEGQuality <= 0 {
+		return jpeg.DefaultQuality
+	}
+	if o.JPEGQuality > 100 {
+		return 100
 	}
-	return false
+	return o.JPEGQuality
 }
 
-// ConvertImgType converts an image from one MIME type to another.
-// A valid MIME type must be passed in for the image to be converted.
-// Reference https://tools.ietf.org/html/rfc2045 and https://tools.ietf.org/html/rfc2046 for valid MIME type.
-//
-// Currently, this function only supports the following MIME type: image/jpeg, image/png
-func ConvertImgType(imgBytes []byte, dstMimeType string) ([]byte, error) {
-	srcMimeType := http.DetectContentType(imgBytes)
-	if srcMimeType == dstMimeType {
-		return imgBytes, nil
+// background returns the background color, defaulting to white
+func (o *EncodeOptions) background() color.Color {
+	if o == nil || o.Background == nil {
+		return color.White
 	}
+	return o.Background
+}
 
-	var err error
-	var encodedImg *bytes.Buffer
+// pngCompressionLevels maps the names accepted by ParsePNGCompression to their compression level
+var pngCompressionLevels = map[string]png.CompressionLevel{
+	"default": png.DefaultCompression,
+	"none":    png.NoCompression,
+	"fast":    png.BestSpeed,
+	"best":    png.BestCompression,
+}
 
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
-	if err != nil {
-		return nil, err
+// ParsePNGCompression is a function for parsing a PNG compression level: default, none, fast or best
+func ParsePNGCompression(level string) (png.CompressionLevel, error) {
+	if l, ok := pngCompressionLevels[strings.ToLower(strings.TrimSpace(level))]; ok {
+		return l, nil
 	}
+	return png.DefaultCompression, fmt.Errorf("unrecognized PNG compression level: '%s'; must be one of default, none, fast, best", level)
+}
 
-	switch dstMimeType {
-	case ContentTypeJPEG:
-		encodedImg, err = encodeImageToJPEG(img)
-	case ContentTypePNG:
-		encodedImg, err = encodeImageToPNG(img)
-	default:
-		return nil, fmt.Errorf("unsupported destination MIME type '%s'", dstMimeType)
+// ParseColor is a function for parsing an opaque color name (white, black) or hex color e.g. '#fff' or '#ffffff'
+func ParseColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "white":
+		return color.White, nil
+	case "black":
+		return color.Black, nil
 	}
 
-	if err != nil {
-		return nil, err
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("unrecognized color: '%s'; must be a name (white, black) or a hex color e.g. '#ffffff'", s)
 	}
 
-	return encodedImg.Bytes(), nil
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
 }
 
-// encodeImageToJPEG is a helper function for encoding an image to JPEG format
-func encodeImageToJPEG(img image.Image) (*bytes.Buffer, error) {
-	buf := new(bytes.Buffer)
-	err := jpeg.Encode(buf, img, nil)
+// flatten is a helper function for compositing an image with transparency onto an opaque background color.
+// Opaque images are returned as is.
+func flatten(img image.Image, bg color.Color) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+
+	// Only opaque backgrounds remove transparency
+	r, g, b, _ := bg.RGBA()
+	bg = color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff}
 
-	return buf, err
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+
+	return dst
+}
+
+// checkSupportedContentType is a helper function for checking supported content types for conversion
+func checkSupportedContentType(contentType string) bool {
+	f, ok := LookupFormat(contentType)
+	return ok && f.CanEncode()
+}
+
+// ConvertImgType converts an image from one MIME type to another.
+// A valid MIME type must be passed in for the image to be converted.
+// Reference https://tools.ietf.org/html/rfc2045 and https://tools.ietf.org/html/rfc2046 for valid MIME type.
+//
+// Images of any supported format (see Formats) can be converted to the formats which can be encoded:
+// image/jpeg, image/png, image/gif, image/bmp and image/tiff. Format names e.g. 'jpg' are accepted too.
+func ConvertImgType(imgBytes []byte, dstMimeType string) ([]byte, error) {
+	return ConvertImgTypeWithOptions(imgBytes, dstMimeType, nil)
 }
 
-// encodeImageToPNG is a helper function for encoding an image to PNG format
-func encodeImageToPNG(img image.Image) (*bytes.Buffer, error) {
-	buf := new(bytes.Buffer)
-	err := png.Encode(buf, img)
+// ConvertImgTypeWithOptions converts an image from one MIME type to another like ConvertImgType,
+// encoding it with the specified options (nil for the defaults).
+// Images already of the destination MIME type are returned unchanged.
+func ConvertImgTypeWithOptions(imgBytes []byte, dstMimeType string, opts *EncodeOptions) ([]byte, error) {
+	dst, ok := LookupFormat(dstMimeType)
+	if !ok || !dst.CanEncode() {
+		return nil, fmt.Errorf("unsupported destination MIME type '%s'", dstMimeType)
+	}
+
+	if src, ok := DetectFormat(imgBytes); ok && src == dst {
+		return imgBytes, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, err
+	}
 
-	return buf, err
+	return encodeFormat(img, dst.ContentType, opts)
 }
 
 // updatePathExtension is a helper function for updating the extension of a filepath
 // based on a given content type.
 func updatePathExtension(filepath, contentType string) string {
-	return strings.TrimSuffix(filepath, path.Ext(filepath)) + "." + strings.Split(contentType, "/")[1]
+	ext := "." + strings.Split(contentType, "/")[len(strings.Split(contentType, "/"))-1]
+	if f, ok := LookupFormat(contentType); ok {
+		ext = f.Extension
+	}
+
+	return strings.TrimSuffix(filepath, path.Ext(filepath)) + ext
 }
@@ -4,13 +4,16 @@
  * File Created: Sunday, 5th April 2020 3:36:47 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Wednesday, 5th January 2022 12:06:07 pm
+ * Last Modified: Saturday, 17th October 2026 6:30:47 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
 
 import (
-	"net/http"
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +37,10 @@ func TestEncode(t *testing.T) {
 	}{
 		"PNG to JPEG":         {pngImg, "image/jpeg", false},
 		"JPEG to PNG":         {jpegImg, "image/png", false},
+		"PNG to GIF":          {pngImg, "image/gif", false},
+		"JPEG to BMP":         {jpegImg, "image/bmp", false},
+		"PNG to TIFF":         {pngImg, "image/tiff", false},
+		"JPEG to WebP":        {jpegImg, "image/webp", true},
 		"JPEG to Unsupported": {jpegImg, "image/svg", true},
 	}
 
@@ -49,8 +56,90 @@ func TestEncode(t *testing.T) {
 			continue
 		}
 
-		mimeType := http.DetectContentType(imgResult)
+		mimeType := DetectContentType(imgResult)
 
 		assert.Equal(t, test.conversion, mimeType)
 	}
 }
+
+func TestEncodeOptions(t *testing.T) {
+	// Transparent apart from a red dot at (2, 3)
+	pngImg, _ := test_utils.NewImage("image/png", 40, 20)
+	patternImg, _ := test_utils.NewPatternImage("image/png", 200, 200, 1)
+
+	tests := map[string]struct {
+		img        []byte
+		conversion string
+		opts       *EncodeOptions
+		background color.Color
+	}{
+		"Default Background":   {pngImg, ContentTypeJPEG, nil, color.White},
+		"Black Background":     {pngImg, ContentTypeJPEG, &EncodeOptions{Background: color.Black}, color.Black},
+		"Custom Background":    {pngImg, ContentTypeGIF, &EncodeOptions{Background: color.RGBA{0, 0, 255, 255}}, color.RGBA{0, 0, 255, 255}},
+		"Transparency Kept":    {pngImg, ContentTypeTIFF, &EncodeOptions{Background: color.Black}, color.Transparent},
+		"JPEG Quality":         {patternImg, ContentTypeJPEG, &EncodeOptions{JPEGQuality: 10}, nil},
+		"PNG No Compression":   {patternImg, ContentTypePNG, &EncodeOptions{PNGCompression: png.NoCompression}, nil},
+		"PNG Best Compression": {patternImg, ContentTypePNG, &EncodeOptions{PNGCompression: png.BestCompression}, nil},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		src := test.img
+		if test.conversion == ContentTypePNG {
+			// Converting to the same format is a no-op
+			src, _ = ConvertImgType(test.img, ContentTypeTIFF)
+		}
+
+		converted, err := ConvertImgTypeWithOptions(src, test.conversion, test.opts)
+		assert.NoError(t, err)
+
+		defaults, err := ConvertImgType(src, test.conversion)
+		assert.NoError(t, err)
+
+		switch {
+		case test.background != nil:
+			img, _, err := image.Decode(bytes.NewReader(converted))
+			assert.NoError(t, err)
+
+			// Compare away from the red dot, allowing for lossy encoding
+			er, eg, eb, ea := test.background.RGBA()
+			r, g, b, a := img.At(30, 15).RGBA()
+			assert.InDelta(t, er>>8, r>>8, 8)
+			assert.InDelta(t, eg>>8, g>>8, 8)
+			assert.InDelta(t, eb>>8, b>>8, 8)
+			assert.InDelta(t, ea>>8, a>>8, 8)
+		case test.opts.JPEGQuality != 0:
+			assert.Less(t, len(converted), len(defaults))
+		case test.opts.PNGCompression == png.NoCompression:
+			assert.Greater(t, len(converted), len(defaults))
+		default:
+			assert.LessOrEqual(t, len(converted), len(defaults))
+		}
+	}
+}
+
+func TestParseEncodeOptions(t *testing.T) {
+	for s, expected := range map[string]color.Color{
+		"white":   color.White,
+		"Black":   color.Black,
+		"#f00":    color.RGBA{255, 0, 0, 255},
+		"#00ff80": color.RGBA{0, 255, 128, 255},
+		"#00ff8":  nil,
+		"blue":    nil,
+	} {
+		c, err := ParseColor(s)
+		if expected == nil {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c)
+	}
+
+	level, err := ParsePNGCompression("best")
+	assert.NoError(t, err)
+	assert.Equal(t, png.BestCompression, level)
+	_, err = ParsePNGCompression("max")
+	assert.Error(t, err)
+}
@@ -0,0 +1,226 @@
+// Package image provides image processing utilities
+/*
+ * File: format.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:27:43 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:57:01 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+
+	// Register the WebP decoder; there is no WebP encoder
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ContentTypeJPEG is the 'image/jpeg' MIME type
+	ContentTypeJPEG = "image/jpeg"
+	// ContentTypePNG is the 'image/png' MIME type
+	ContentTypePNG = "image/png"
+	// ContentTypeGIF is the 'image/gif' MIME type
+	ContentTypeGIF = "image/gif"
+	// ContentTypeBMP is the 'image/bmp' MIME type
+	ContentTypeBMP = "image/bmp"
+	// ContentTypeTIFF is the 'image/tiff' MIME type
+	ContentTypeTIFF = "image/tiff"
+	// ContentTypeWebP is the 'image/webp' MIME type
+	ContentTypeWebP = "image/webp"
+)
+
+// Format is a struct for representing a supported image format
+type Format struct {
+	// Name is the short name of the format e.g. 'jpeg'; also the name reported by image.Decode
+	Name string
+	// ContentType is the MIME type of the format e.g. 'image/jpeg'
+	ContentType string
+	// Extension is the file extension of converted images, including the leading '.'
+	Extension string
+	// Aliases are alternative names and MIME types accepted by LookupFormat e.g. 'jpg'
+	Aliases []string
+	// Transparency is true if the format can be encoded with an alpha channel. Transparent images
+	// encoded in other formats are flattened onto the background color of the EncodeOptions.
+	Transparency bool
+
+	// magic are the leading bytes identifying the format; '?' matches any byte
+	magic []string
+	// encode encodes an image in the format; nil if the format can only be decoded
+	encode func(w io.Writer, img image.Image, opts *EncodeOptions) error
+}
+
+// CanEncode returns true if images can be converted to the format
+func (f *Format) CanEncode() bool {
+	return f.encode != nil
+}
+
+// Encode is a method for encoding an image in the format with the specified options (nil for the defaults).
+// Transparent images are flattened onto the background color if the format has no transparency.
+func (f *Format) Encode(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	if f.encode == nil {
+		return fmt.Errorf("encoding MIME type '%s' is not supported", f.ContentType)
+	}
+	if opts == nil {
+		opts = &EncodeOptions{}
+	}
+
+	if !f.Transparency {
+		img = flatten(img, opts.background())
+	}
+
+	return f.encode(w, img, opts)
+}
+
+// formats are the supported image formats. Every format can be decoded.
+var formats = []*Format{
+	{
+		Name: "jpeg", ContentType: ContentTypeJPEG, Extension: ".jpeg", Aliases: []string{"jpg", "image/jpg", "image/pjpeg"},
+		magic: []string{"\xff\xd8\xff"},
+		encode: func(w io.Writer, img image.Image, opts *EncodeOptions) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.jpegQuality()})
+		},
+	},
+	{
+		Name: "png", ContentType: ContentTypePNG, Extension: ".png", Transparency: true,
+		magic: []string{"\x89PNG\r\n\x1a\n"},
+		encode: func(w io.Writer, img image.Image, opts *EncodeOptions) error {
+			return (&png.Encoder{CompressionLevel: opts.PNGCompression}).Encode(w, img)
+		},
+	},
+	{
+		Name: "gif", ContentType: ContentTypeGIF, Extension: ".gif",
+		magic:  []string{"GIF87a", "GIF89a"},
+		encode: func(w io.Writer, img image.Image, opts *EncodeOptions) error { return gif.Encode(w, img, nil) },
+	},
+	{
+		Name: "bmp", ContentType: ContentTypeBMP, Extension: ".bmp", Aliases: []string{"image/x-ms-bmp", "image/x-bmp"}, Transparency: true,
+		magic:  []string{"BM"},
+		encode: func(w io.Writer, img image.Image, opts *EncodeOptions) error { return bmp.Encode(w, img) },
+	},
+	{
+		Name: "tiff", ContentType: ContentTypeTIFF, Extension: ".tiff", Aliases: []string{"tif"}, Transparency: true,
+		magic:  []string{"II*\x00", "MM\x00*"},
+		encode: func(w io.Writer, img image.Image, opts *EncodeOptions) error { return tiff.Encode(w, img, nil) },
+	},
+	{
+		Name: "webp", ContentType: ContentTypeWebP, Extension: ".webp", Transparency: true,
+		magic: []string{"RIFF????WEBPVP8"},
+	},
+}
+
+// Formats returns the supported image formats
+func Formats() []*Format {
+	return append([]*Format{}, formats...)
+}
+
+// EncodableTypes returns the sorted MIME types images can be converted to
+func EncodableTypes() []string {
+	var types []string
+	for _, f := range formats {
+		if f.CanEncode() {
+			types = append(types, f.ContentType)
+		}
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// LookupFormat is a function for retrieving a supported format by its MIME type, name or alias
+// e.g. 'image/jpeg', 'jpeg' or 'jpg'. MIME type parameters are ignored.
+func LookupFormat(name string) (*Format, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if mediaType, _, err := mime.ParseMediaType(name); err == nil {
+		name = mediaType
+	}
+	name = strings.TrimPrefix(name, ".")
+
+	for _, f := range formats {
+		if name == f.Name || name == f.ContentType {
+			return f, true
+		}
+		for _, alias := range f.Aliases {
+			if name == alias {
+				return f, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// DetectFormat is a function for identifying the format of an image from its leading bytes
+func DetectFormat(imgBytes []byte) (*Format, bool) {
+	for _, f := range formats {
+		for _, magic := range f.magic {
+			if matchMagic(imgBytes, magic) {
+				return f, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// DetectContentType is a function for retrieving the MIME type of an image from its leading bytes.
+// Falls back to http.DetectContentType for unsupported formats e.g. 'text/html; charset=utf-8'.
+func DetectContentType(imgBytes []byte) string {
+	if f, ok := DetectFormat(imgBytes); ok {
+		return f.ContentType
+	}
+	return http.DetectContentType(imgBytes)
+}
+
+// matchMagic is a helper function for matching leading bytes against a magic pattern with '?' wildcards
+func matchMagic(b []byte, magic string) bool {
+	if len(b) < len(magic) {
+		return false
+	}
+	for i := 0; i < len(magic); i++ {
+		if magic[i] != '?' && magic[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// outputContentType is a helper function for retrieving the MIME type an image is re-encoded to when no format
+// is given: its own MIME type if it can be encoded, otherwise PNG e.g. for WebP images which can only be decoded
+func outputContentType(contentType string) string {
+	if f, ok := LookupFormat(contentType); ok && f.CanEncode() {
+		return f.ContentType
+	}
+	return ContentTypePNG
+}
+
+// encodeFormat is a helper function for encoding an image in the format of the specified MIME type
+func encodeFormat(img image.Image, contentType string, opts *EncodeOptions) ([]byte, error) {
+	f, ok := LookupFormat(contentType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported MIME type '%s'", contentType)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := f.Encode(buf, img, opts); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
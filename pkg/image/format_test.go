@@ -0,0 +1,100 @@
+/*
+ * File: format_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:28:38 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:57:01 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestFormats(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		img         []byte
+		encode      bool
+	}{
+		"JPEG": {ContentTypeJPEG, nil, true},
+		"PNG":  {ContentTypePNG, nil, true},
+		"GIF":  {ContentTypeGIF, nil, true},
+		"BMP":  {ContentTypeBMP, nil, true},
+		"TIFF": {ContentTypeTIFF, nil, true},
+		"WebP": {ContentTypeWebP, test_utils.NewWebPImage(), false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		img := test.img
+		if img == nil {
+			var err error
+			img, err = test_utils.NewImage(test.contentType, 40, 20)
+			assert.NoError(t, err)
+		}
+
+		// Sniffed from the magic bytes and decodable
+		f, ok := DetectFormat(img)
+		assert.True(t, ok)
+		assert.Equal(t, test.contentType, f.ContentType)
+		assert.Equal(t, test.contentType, DetectContentType(img))
+		assert.Equal(t, test.encode, f.CanEncode())
+
+		stats, err := Validate(bytes.NewReader(img), DefaultMaxPixels)
+		assert.NoError(t, err)
+		assert.Equal(t, f.Name, stats.ContentType)
+
+		// Convertible to and from every encodable format
+		for _, dst := range EncodableTypes() {
+			converted, err := ConvertImgType(img, dst)
+			assert.NoError(t, err)
+			assert.Equal(t, dst, DetectContentType(converted))
+		}
+
+		// Resized in their own format, or PNG if it can only be decoded
+		resized, err := ResizeImage(img, 10, 0)
+		assert.NoError(t, err)
+		if test.encode {
+			assert.Equal(t, test.contentType, DetectContentType(resized))
+		} else {
+			assert.Equal(t, ContentTypePNG, DetectContentType(resized))
+		}
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+	tests := map[string]struct {
+		name        string
+		contentType string
+		ok          bool
+	}{
+		"MIME Type":       {"image/gif", ContentTypeGIF, true},
+		"MIME Parameters": {"image/png; charset=binary", ContentTypePNG, true},
+		"Name":            {"TIFF", ContentTypeTIFF, true},
+		"Alias":           {"jpg", ContentTypeJPEG, true},
+		"Extension":       {".tif", ContentTypeTIFF, true},
+		"Unsupported":     {"image/svg+xml", "", false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		f, ok := LookupFormat(test.name)
+		assert.Equal(t, test.ok, ok)
+		if ok {
+			assert.Equal(t, test.contentType, f.ContentType)
+		}
+	}
+
+	assert.Equal(t, []string{ContentTypeBMP, ContentTypeGIF, ContentTypeJPEG, ContentTypePNG, ContentTypeTIFF}, EncodableTypes())
+	assert.Equal(t, "/a/b.tiff", updatePathExtension("/a/b.png", "tif"))
+	assert.Equal(t, "/a/b.jpeg", updatePathExtension("/a/b.png", ContentTypeJPEG))
+}
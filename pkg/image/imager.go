@@ -5,17 +5,21 @@
  * File Created: Saturday, 4th April 2020 7:16:14 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Friday, 26th March 2021 5:35:57 pm
+ * Last Modified: Saturday, 17th October 2026 7:05:55 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
 
 import (
+	"context"
 	"fmt"
+	"image/color"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	guuid "github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
+	"gitlab.com/krydus/emeraldai/emerald-tooling/pkg/worker"
 )
 
 // Imager is a struct for holding an Imager's context variables
@@ -24,21 +28,63 @@ type Imager struct {
 	TypeConversion *string
 	// SizeConversion is the resize parameters to use when resizing images
 	SizeConversion *SizeConversionParams
+	// Operations is the pipeline applied to images in order, replacing TypeConversion and SizeConversion if set
+	Operations []Operation
+	// Encoding is the settings converted and resized images are encoded with; nil for the defaults
+	Encoding *EncodeOptions
 
 	// Concurrency of this Imager
 	Concurrency int
+	// QueueSize is the number of images buffered ahead of and behind the workers
+	QueueSize int
+	// TaskTimeout is the maximum duration of a single image (0 for unlimited).
+	// Images are only interrupted between the decode, operation and encode steps, so a slow step
+	// e.g. decoding a very large image is not cut short.
+	TaskTimeout time.Duration
+	// Ordered outputs images in the order they were received
+	Ordered bool
+
+	// Summary counters
+	processed, failed int64
 
 	// Sync vars
-	InChan   chan *Image
-	OutChan  chan *Image
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	InChan    chan *Image
+	OutChan   chan *Image
+	ops       []Operation
+	pool      *worker.Pool
+	stopChan  chan struct{}
+	finished  chan struct{}
+	closeOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// Summary is a struct for representing the outcome of an Imager's run
+type Summary struct {
+	// Processed is the number of images output on the OutChan
+	Processed int `json:"processed"`
+	// Failed is the number of processed images with an error
+	Failed int `json:"failed"`
+}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("processed: %d, failed: %d", s.Processed, s.Failed)
 }
 
 // SizeConversionParams is a struct containing the image resize params
 type SizeConversionParams struct {
 	Height int
 	Width  int
+
+	// Mode is how the image is fit to the width and height; defaults to ResizeStretch
+	Mode ResizeMode
+	// Crop is the region kept by ResizeFill: an anchor e.g. 'center' or 'top-left', or CropSmart; defaults to center
+	Crop string
+	// PadColor is the color of the borders added by ResizePad; defaults to black
+	PadColor color.Color
+	// NoUpscale never enlarges images, leaving images smaller than the width and height at their size
+	NoUpscale bool
+	// Filter is the resampling filter: nearest, box, linear, catmullrom, mitchell or lanczos; defaults to lanczos
+	Filter string
 }
 
 // Image is a struct for representing an image to be processed
@@ -47,13 +93,26 @@ type Image struct {
 	ProcessedFilepath string
 	ImageBytes        []byte
 	Err               error
+
+	// Tag is arbitrary caller data passed through processing unchanged e.g. metadata of the input
+	Tag interface{}
 }
 
 // NewImager is a function for initializing a new Imager object
 func NewImager(concurrency int, typeConv *string, sizeConv *SizeConversionParams) (*Imager, error) {
 	if typeConv != nil {
 		if !checkSupportedContentType(*typeConv) {
-			return nil, fmt.Errorf("unsupported type conversion '%s'", *typeConv)
+			return nil, fmt.Errorf("unsupported type conversion '%s'; must be one of %s", *typeConv, strings.Join(EncodableTypes(), ", "))
+		}
+
+		// Normalize format names and aliases e.g. 'jpg' to their MIME type
+		f, _ := LookupFormat(*typeConv)
+		typeConv = &f.ContentType
+	}
+
+	if sizeConv != nil {
+		if err := sizeConv.Validate(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -61,66 +120,150 @@ func NewImager(concurrency int, typeConv *string, sizeConv *SizeConversionParams
 		TypeConversion: typeConv,
 		SizeConversion: sizeConv,
 		Concurrency:    concurrency,
-		InChan:         make(chan *Image, 100),
-		OutChan:        make(chan *Image, 100),
-		stopChan:       make(chan struct{}, 1),
+		QueueSize:      100,
+		InChan:         make(chan *Image),
+		OutChan:        make(chan *Image),
+		stopChan:       make(chan struct{}),
+		finished:       make(chan struct{}),
 	}, nil
 }
 
 // Start kicks off the Imager worker routines
 func (imgr *Imager) Start() error {
-
-	// Start a fixed number of goroutines to read and digest images.
-	imgr.wg.Add(imgr.Concurrency)
-	for i := 0; i < imgr.Concurrency; i++ {
-		go func() {
-			imgr.work()
-			imgr.wg.Done()
-		}()
+	imgr.ops = imgr.Operations
+	if len(imgr.ops) == 0 {
+		if imgr.TypeConversion != nil {
+			imgr.ops = append(imgr.ops, &FormatOperation{ContentType: *imgr.TypeConversion})
+		}
+		if imgr.SizeConversion != nil {
+			imgr.ops = append(imgr.ops, &ResizeOperation{Params: *imgr.SizeConversion})
+		}
 	}
 
+	imgr.pool = worker.NewPool(context.Background(), imgr.work, worker.Options{
+		Workers:    imgr.Concurrency,
+		QueueSize:  imgr.QueueSize,
+		OutputSize: imgr.QueueSize,
+		Timeout:    imgr.TaskTimeout,
+		Ordered:    imgr.Ordered,
+	})
+	imgr.pool.Start()
+
+	go imgr.feed()
+	go imgr.forward()
+
 	return nil
 }
 
-// Stop the Imager worker routines and wait for graceful exit
+// Stop aborts the Imager worker routines and waits for them to exit.
+// Queued images are dropped; use Close to finish queued work. The OutChan is closed once the workers exit.
 func (imgr *Imager) Stop() {
-	close(imgr.stopChan)
-	imgr.wg.Wait()
+	imgr.stopOnce.Do(func() {
+		close(imgr.stopChan)
+	})
+	if imgr.pool == nil {
+		return
+	}
+
+	imgr.pool.Stop()
+	<-imgr.finished
+}
+
+// Close stops accepting input, waits for the queued images to be processed and closes the OutChan.
+// Returns a summary of the run. Nothing may be sent on the InChan once Close is called.
+//
+// Close blocks until the processed images are received from the OutChan, so it must be called
+// from a different goroutine than the one receiving them, e.g. the one sending the input.
+func (imgr *Imager) Close() *Summary {
+	imgr.closeOnce.Do(func() {
+		close(imgr.InChan)
+	})
+	if imgr.pool != nil {
+		<-imgr.finished
+	}
+
+	return &Summary{
+		Processed: int(atomic.LoadInt64(&imgr.processed)),
+		Failed:    int(atomic.LoadInt64(&imgr.failed)),
+	}
 }
 
-func (imgr *Imager) work() {
-	id := guuid.New()
-	log.Debugf("Starting Imager worker routine [%s]\n", id.String())
-	defer log.Debugf("Exiting Imager worker routine [%s]\n", id.String())
+// Stats returns a snapshot of the Imager's worker counters
+func (imgr *Imager) Stats() worker.Stats {
+	if imgr.pool == nil {
+		return worker.Stats{}
+	}
+	return imgr.pool.Stats()
+}
 
+// feed is a helper function for submitting the images received on the InChan to the worker pool.
+// The pool is closed once the InChan is closed.
+func (imgr *Imager) feed() {
 	for {
 		select {
-		case img := <-imgr.InChan:
-			img.ProcessedFilepath = img.OriginalFilepath
-
-			if imgr.TypeConversion != nil {
-				imgC, err := ConvertImgType(img.ImageBytes, *imgr.TypeConversion)
-				if err != nil {
-					img.Err = err
-				} else {
-					img.ImageBytes = imgC
-					img.ProcessedFilepath = updatePathExtension(img.OriginalFilepath, *imgr.TypeConversion)
-				}
+		case img, ok := <-imgr.InChan:
+			if !ok {
+				imgr.pool.Close()
+				return
 			}
-
-			if imgr.SizeConversion != nil {
-				imgR, err := ResizeImage(img.ImageBytes, imgr.SizeConversion.Width, imgr.SizeConversion.Height)
-				if err != nil {
-					img.Err = err
-				} else {
-					img.ImageBytes = imgR
-				}
+			if err := imgr.pool.Submit(img); err != nil {
+				return
 			}
 
-			imgr.OutChan <- img
-
 		case <-imgr.stopChan:
 			return
 		}
 	}
 }
+
+// forward is a helper function for outputting the images processed by the worker pool on the OutChan.
+// The OutChan is closed once the pool is drained or stopped.
+func (imgr *Imager) forward() {
+	defer close(imgr.finished)
+	defer close(imgr.OutChan)
+
+	for r := range imgr.pool.Results() {
+		img := r.Item.(*Image)
+		if r.Err != nil {
+			img.Err = r.Err
+		}
+
+		// Abandon the image rather than block on a consumer which has gone away
+		select {
+		case imgr.OutChan <- img:
+			atomic.AddInt64(&imgr.processed, 1)
+			if img.Err != nil {
+				atomic.AddInt64(&imgr.failed, 1)
+			}
+		case <-imgr.stopChan:
+		}
+	}
+}
+
+// work is a worker.Func for applying the operations to an image, returning the processed Image and its error
+func (imgr *Imager) work(ctx context.Context, item interface{}) (interface{}, error) {
+	img := item.(*Image)
+	img.ProcessedFilepath = img.OriginalFilepath
+
+	srcType := DetectContentType(img.ImageBytes)
+	processed, contentType, err := ApplyOperations(ctx, img.ImageBytes, imgr.ops, imgr.Encoding)
+	if err != nil {
+		img.Err = err
+		return img, err
+	}
+
+	// Images converted to another format e.g. decode-only WebP re-encoded as PNG get a matching extension
+	converted := contentType != srcType
+	for _, op := range imgr.ops {
+		if _, ok := op.(*FormatOperation); ok {
+			converted = true
+			break
+		}
+	}
+	if converted {
+		img.ProcessedFilepath = updatePathExtension(img.OriginalFilepath, contentType)
+	}
+	img.ImageBytes = processed
+
+	return img, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * File: imager_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:19:24 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:01:51 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestImagerClose(t *testing.T) {
+	jpegImg, _ := test_utils.NewImage("image/jpeg", 40, 20)
+
+	imgr, err := NewImager(4, nil, &SizeConversionParams{Width: 10, Height: 10})
+	assert.NoError(t, err)
+	imgr.Start()
+
+	// Queue more images than the channels buffer, as a stream of stdin would
+	const count = 250
+	summaries := make(chan *Summary, 1)
+	go func() {
+		for i := 0; i < count; i++ {
+			if i%50 == 0 {
+				imgr.InChan <- &Image{ImageBytes: []byte("not an image")}
+				continue
+			}
+			imgr.InChan <- &Image{ImageBytes: jpegImg}
+		}
+		summaries <- imgr.Close()
+	}()
+
+	received := 0
+	for img := range imgr.OutChan {
+		received++
+		if img.Err == nil {
+			assert.NotEmpty(t, img.ImageBytes)
+		}
+	}
+
+	assert.Equal(t, count, received)
+	assert.Equal(t, &Summary{Processed: count, Failed: 5}, <-summaries)
+
+	// Stop is safe to call after Close
+	imgr.Stop()
+}
+
+func TestImagerStop(t *testing.T) {
+	jpegImg, _ := test_utils.NewImage("image/jpeg", 40, 20)
+
+	imgr, err := NewImager(2, nil, nil)
+	assert.NoError(t, err)
+	imgr.Start()
+
+	// Fill the OutChan without a consumer
+	for i := 0; i < 150; i++ {
+		imgr.InChan <- &Image{ImageBytes: jpegImg}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		imgr.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestImagerOperations(t *testing.T) {
+	pngImg, _ := test_utils.NewImage("image/png", 40, 20)
+	format := "png"
+
+	tests := map[string]struct {
+		imgr        func() (*Imager, error)
+		img         []byte
+		src         string
+		contentType string
+		path        string
+		width       int
+	}{
+		"Operations": {func() (*Imager, error) {
+			imgr, err := NewImager(2, nil, nil)
+			if err == nil {
+				imgr.Operations, err = ParseOperations("resize:width=10;format:jpg")
+			}
+			return imgr, err
+		}, pngImg, "/tmp/a.png", ContentTypeJPEG, "/tmp/a.jpeg", 10},
+		"Conversions": {func() (*Imager, error) {
+			return NewImager(2, &format, &SizeConversionParams{Width: 20})
+		}, pngImg, "/tmp/a.png", ContentTypePNG, "/tmp/a.png", 20},
+		"WebP Resize": {func() (*Imager, error) {
+			return NewImager(2, nil, &SizeConversionParams{Width: 30})
+		}, test_utils.NewWebPImage(), "/tmp/a.webp", ContentTypePNG, "/tmp/a.png", 30},
+		"WebP Conversion": {func() (*Imager, error) {
+			return NewImager(2, &format, nil)
+		}, test_utils.NewWebPImage(), "/tmp/a.webp", ContentTypePNG, "/tmp/a.png", 75},
+		"WebP No Operations": {func() (*Imager, error) {
+			return NewImager(2, nil, nil)
+		}, test_utils.NewWebPImage(), "/tmp/a.webp", ContentTypeWebP, "/tmp/a.webp", 75},
+		"No Operations": {func() (*Imager, error) {
+			return NewImager(2, nil, nil)
+		}, pngImg, "/tmp/a.png", ContentTypePNG, "/tmp/a.png", 40},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		imgr, err := test.imgr()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		imgr.Start()
+
+		imgr.InChan <- &Image{ImageBytes: test.img, OriginalFilepath: test.src}
+		img := <-imgr.OutChan
+		imgr.Stop()
+
+		if !assert.NoError(t, img.Err) {
+			continue
+		}
+		assert.Equal(t, test.contentType, DetectContentType(img.ImageBytes))
+		assert.Equal(t, test.path, img.ProcessedFilepath)
+
+		stats, err := GetStats(img.ImageBytes)
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.width, stats.Width)
+		}
+	}
+}
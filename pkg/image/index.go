@@ -0,0 +1,198 @@
+// Package image provides image processing utilities
+/*
+ * File: index.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 5:37:44 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:37:44 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"sort"
+	"sync"
+)
+
+// HashMatch is a struct for representing an indexed image matching a query hash
+type HashMatch struct {
+	ID       string
+	Hash     uint64
+	Distance int
+}
+
+// HashIndex is a BK-tree over perceptual hashes using the Hamming distance as metric.
+// Range queries only descend into the subtrees whose edge distance lies within the
+// query radius of the triangle inequality, so searching for near-duplicates with a
+// small radius visits a small fraction of the indexed hashes.
+//
+// HashIndex is safe for concurrent use.
+type HashIndex struct {
+	root *bkNode
+	size int
+	sync.RWMutex
+}
+
+// bkNode is a node of the BK-tree. Identical hashes share a node.
+type bkNode struct {
+	hash     uint64
+	ids      []string
+	children map[int]*bkNode
+}
+
+// NewHashIndex is a function for initializing an empty HashIndex
+func NewHashIndex() *HashIndex {
+	return &HashIndex{}
+}
+
+// Len returns the number of indexed IDs
+func (idx *HashIndex) Len() int {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	return idx.size
+}
+
+// Add indexes the ID under the specified hash
+func (idx *HashIndex) Add(hash uint64, id string) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	idx.size++
+
+	if idx.root == nil {
+		idx.root = &bkNode{hash: hash, ids: []string{id}}
+		return
+	}
+
+	node := idx.root
+	for {
+		d := HammingDistance(node.hash, hash)
+		if d == 0 {
+			node.ids = append(node.ids, id)
+			return
+		}
+
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{hash: hash, ids: []string{id}}
+			return
+		}
+		node = child
+	}
+}
+
+// Search returns all indexed IDs within maxDist of the specified hash, ordered by distance
+func (idx *HashIndex) Search(hash uint64, maxDist int) []HashMatch {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	matches := []HashMatch{}
+	if idx.root == nil {
+		return matches
+	}
+
+	stack := []*bkNode{idx.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := HammingDistance(node.hash, hash)
+		if d <= maxDist {
+			for _, id := range node.ids {
+				matches = append(matches, HashMatch{ID: id, Hash: node.hash, Distance: d})
+			}
+		}
+
+		for edge, child := range node.children {
+			if edge >= d-maxDist && edge <= d+maxDist {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+
+	return matches
+}
+
+// Nearest returns the closest indexed ID within maxDist of the specified hash
+func (idx *HashIndex) Nearest(hash uint64, maxDist int) (HashMatch, bool) {
+	matches := idx.Search(hash, maxDist)
+	if len(matches) == 0 {
+		return HashMatch{}, false
+	}
+
+	return matches[0], true
+}
+
+// Matcher is a struct for detecting near-duplicate images among all images seen so far
+type Matcher struct {
+	// Kind is the perceptual hashing algorithm
+	Kind HashKind
+	// Threshold is the maximum Hamming distance at which two images are considered near-duplicates
+	Threshold int
+
+	index *HashIndex
+	sync.Mutex
+}
+
+// NewMatcher is a function for initializing a new Matcher.
+// A negative threshold selects DefaultHashThreshold.
+func NewMatcher(kind HashKind, threshold int) *Matcher {
+	if threshold < 0 {
+		threshold = DefaultHashThreshold
+	}
+
+	return &Matcher{
+		Kind:      kind,
+		Threshold: threshold,
+		index:     NewHashIndex(),
+	}
+}
+
+// Hash computes the hash of an encoded image using the Matcher's hashing algorithm
+func (m *Matcher) Hash(imgBytes []byte) (uint64, error) {
+	return FuzzyHash(imgBytes, m.Kind)
+}
+
+// Match returns the closest previously seen image within the Matcher's threshold
+func (m *Matcher) Match(hash uint64) (HashMatch, bool) {
+	return m.index.Nearest(hash, m.Threshold)
+}
+
+// MatchAll returns all previously seen images within the Matcher's threshold, ordered by distance
+func (m *Matcher) MatchAll(hash uint64) []HashMatch {
+	return m.index.Search(hash, m.Threshold)
+}
+
+// Add records an image as seen
+func (m *Matcher) Add(id string, hash uint64) {
+	m.index.Add(hash, id)
+}
+
+// MatchOrAdd returns the closest previously seen image within the Matcher's threshold.
+// If no near-duplicate is found, the image is recorded as seen. The check and record are atomic.
+func (m *Matcher) MatchOrAdd(id string, hash uint64) (HashMatch, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	if match, ok := m.Match(hash); ok {
+		return match, true
+	}
+
+	m.Add(id, hash)
+
+	return HashMatch{}, false
+}
+
+// Len returns the number of images seen
+func (m *Matcher) Len() int {
+	return m.index.Len()
+}
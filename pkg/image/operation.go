@@ -0,0 +1,533 @@
+// Package image provides image processing utilities
+/*
+ * File: operation.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:39:20 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:05:55 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"gopkg.in/yaml.v2"
+)
+
+// Frame is a struct for representing a decoded image passed through a pipeline of Operations
+type Frame struct {
+	// Image is the decoded image
+	Image image.Image
+	// ContentType is the MIME type the image is encoded to once the operations are applied
+	ContentType string
+}
+
+// Operation is an interface for an in-memory image transform applied by the Imager
+type Operation interface {
+	// Name returns the name the operation is registered with e.g. 'resize'
+	Name() string
+	// Apply transforms the frame in place
+	Apply(f *Frame) error
+}
+
+// OperationArgs is the arguments of an operation by name e.g. 'width' -> '224'
+type OperationArgs map[string]string
+
+// OperationFactory is a function for creating an Operation from its arguments
+type OperationFactory func(args OperationArgs) (Operation, error)
+
+// operationDef is a struct for representing a registered operation
+type operationDef struct {
+	// primary is the argument a value given without a name is assigned to e.g. 'format:png'
+	primary string
+	factory OperationFactory
+}
+
+var (
+	operations   = map[string]operationDef{}
+	operationsMu sync.RWMutex
+)
+
+func init() {
+	RegisterOperation("format", "type", newFormatOperation)
+	RegisterOperation("resize", "", newResizeOperation)
+	RegisterOperation("grayscale", "", newGrayscaleOperation)
+	RegisterOperation("flip", "direction", newFlipOperation)
+	RegisterOperation("rotate", "angle", newRotateOperation)
+	RegisterOperation("blur", "sigma", newBlurOperation)
+	RegisterOperation("sharpen", "sigma", newSharpenOperation)
+}
+
+// RegisterOperation is a function for registering an operation by name, replacing any operation of the same name.
+// primary is the argument an unnamed value is assigned to in an operations spec, or empty for none.
+func RegisterOperation(name, primary string, factory OperationFactory) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	operations[strings.ToLower(name)] = operationDef{primary: primary, factory: factory}
+}
+
+// OperationNames is a function for listing the registered operation names, sorted
+func OperationNames() []string {
+	operationsMu.RLock()
+	defer operationsMu.RUnlock()
+
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewOperation is a function for creating a registered operation from its arguments
+func NewOperation(name string, args OperationArgs) (Operation, error) {
+	operationsMu.RLock()
+	def, ok := operations[strings.ToLower(strings.TrimSpace(name))]
+	operationsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unrecognized operation: '%s'; must be one of %s", name, strings.Join(OperationNames(), ", "))
+	}
+
+	if args == nil {
+		args = OperationArgs{}
+	}
+	if v, ok := args[""]; ok {
+		if def.primary == "" {
+			return nil, fmt.Errorf("operation '%s' has no unnamed argument; got '%s'", name, v)
+		}
+		delete(args, "")
+		args[def.primary] = v
+	}
+
+	op, err := def.factory(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operation '%s'; %s", name, err.Error())
+	}
+	return op, nil
+}
+
+// ParseOperations is a function for parsing an operations spec: operations separated by ';', each a name
+// optionally followed by ':' and comma separated key=value arguments e.g.
+// 'resize:width=224,height=224,mode=pad;grayscale;format:png'. A value without a key is assigned to
+// the operation's primary argument.
+func ParseOperations(spec string) ([]Operation, error) {
+	var ops []Operation
+	for _, s := range strings.Split(spec, ";") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		name, argStr := s, ""
+		if i := strings.Index(s, ":"); i >= 0 {
+			name, argStr = s[:i], s[i+1:]
+		}
+
+		args := OperationArgs{}
+		for _, arg := range strings.Split(argStr, ",") {
+			arg = strings.TrimSpace(arg)
+			if arg == "" {
+				continue
+			}
+			key, value := "", arg
+			if i := strings.Index(arg, "="); i >= 0 {
+				key, value = strings.TrimSpace(arg[:i]), strings.TrimSpace(arg[i+1:])
+			}
+			if _, ok := args[key]; ok {
+				return nil, fmt.Errorf("duplicate argument '%s' of operation '%s'", key, name)
+			}
+			args[key] = value
+		}
+
+		op, err := NewOperation(name, args)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operations in spec '%s'", spec)
+	}
+	return ops, nil
+}
+
+// RecipeStep is a struct for declaring an operation in a Recipe; arguments are given alongside the name
+type RecipeStep struct {
+	Op   string        `yaml:"op"`
+	Args OperationArgs `yaml:",inline"`
+}
+
+// Recipe is a struct for declaring a pipeline of operations in YAML e.g.
+//
+//	operations:
+//	  - op: resize
+//	    width: 224
+//	    height: 224
+//	    mode: pad
+//	  - op: format
+//	    type: png
+type Recipe struct {
+	Operations []RecipeStep `yaml:"operations"`
+}
+
+// LoadRecipe is a function for reading the operations of a Recipe from a YAML file
+func LoadRecipe(filepath string) ([]Operation, error) {
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipe Recipe
+	if err := yaml.UnmarshalStrict(b, &recipe); err != nil {
+		return nil, fmt.Errorf("unable to parse recipe '%s'; %s", filepath, err.Error())
+	}
+	if len(recipe.Operations) == 0 {
+		return nil, fmt.Errorf("no operations in recipe '%s'", filepath)
+	}
+
+	ops := make([]Operation, 0, len(recipe.Operations))
+	for _, step := range recipe.Operations {
+		op, err := NewOperation(step.Op, step.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipe '%s'; %s", filepath, err.Error())
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// ApplyOperations is a function for decoding an image once, applying the operations in order and encoding
+// the result once with the specified options (nil for the defaults). Returns the processed image and its
+// content type. Images left unchanged by the operations are returned as is; images of formats which can
+// only be decoded e.g. WebP are otherwise encoded as PNG unless converted by a format operation.
+func ApplyOperations(ctx context.Context, imgBytes []byte, ops []Operation, opts *EncodeOptions) ([]byte, string, error) {
+	contentType := DetectContentType(imgBytes)
+	if len(ops) == 0 {
+		return imgBytes, contentType, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Formats which can only be decoded e.g. WebP are re-encoded as PNG unless converted
+	f := &Frame{Image: img, ContentType: outputContentType(contentType)}
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		if err := op.Apply(f); err != nil {
+			return nil, "", fmt.Errorf("failed applying operation '%s'; %s", op.Name(), err.Error())
+		}
+	}
+
+	if f.Image == img && f.ContentType == contentType {
+		return imgBytes, contentType, nil
+	}
+
+	// Decoding, operations and encoding can't be interrupted, so the context is checked between them
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	encoded, err := encodeFormat(f.Image, f.ContentType, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return encoded, f.ContentType, nil
+}
+
+// Check is a method for rejecting arguments other than the specified keys
+func (a OperationArgs) Check(keys ...string) error {
+	allowed := map[string]bool{}
+	for _, k := range keys {
+		allowed[k] = true
+	}
+	for k := range a {
+		if !allowed[k] {
+			return fmt.Errorf("unrecognized argument '%s'; must be one of %s", k, strings.Join(keys, ", "))
+		}
+	}
+	return nil
+}
+
+// String is a method for reading a string argument, returning def if unset
+func (a OperationArgs) String(key, def string) string {
+	if v, ok := a[key]; ok {
+		return v
+	}
+	return def
+}
+
+// Int is a method for reading an integer argument, returning def if unset
+func (a OperationArgs) Int(key string, def int) (int, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("argument '%s' must be an integer; got '%s'", key, v)
+	}
+	return i, nil
+}
+
+// Float is a method for reading a float argument, returning def if unset
+func (a OperationArgs) Float(key string, def float64) (float64, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("argument '%s' must be a number; got '%s'", key, v)
+	}
+	return f, nil
+}
+
+// Bool is a method for reading a boolean argument, returning def if unset
+func (a OperationArgs) Bool(key string, def bool) (bool, error) {
+	v, ok := a[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("argument '%s' must be a boolean; got '%s'", key, v)
+	}
+	return b, nil
+}
+
+// FormatOperation is an Operation for converting the image to another format
+type FormatOperation struct {
+	ContentType string
+}
+
+func newFormatOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("type"); err != nil {
+		return nil, err
+	}
+
+	f, ok := LookupFormat(args.String("type", ""))
+	if !ok || !f.CanEncode() {
+		return nil, fmt.Errorf("unsupported type '%s'; must be one of %s", args.String("type", ""), strings.Join(EncodableTypes(), ", "))
+	}
+	return &FormatOperation{ContentType: f.ContentType}, nil
+}
+
+// Name returns the operation name
+func (o *FormatOperation) Name() string { return "format" }
+
+// Apply sets the content type the frame is encoded to
+func (o *FormatOperation) Apply(f *Frame) error {
+	f.ContentType = o.ContentType
+	return nil
+}
+
+// ResizeOperation is an Operation for resizing the image (see SizeConversionParams)
+type ResizeOperation struct {
+	Params SizeConversionParams
+}
+
+func newResizeOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("width", "height", "mode", "crop", "pad-color", "no-upscale", "filter"); err != nil {
+		return nil, err
+	}
+
+	var err error
+	o := &ResizeOperation{Params: SizeConversionParams{
+		Mode:   ResizeMode(strings.ToLower(args.String("mode", ""))),
+		Crop:   args.String("crop", ""),
+		Filter: args.String("filter", ""),
+	}}
+	if o.Params.Width, err = args.Int("width", 0); err != nil {
+		return nil, err
+	}
+	if o.Params.Height, err = args.Int("height", 0); err != nil {
+		return nil, err
+	}
+	if o.Params.NoUpscale, err = args.Bool("no-upscale", false); err != nil {
+		return nil, err
+	}
+	if c, ok := args["pad-color"]; ok {
+		if o.Params.PadColor, err = ParseColor(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.Params.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Name returns the operation name
+func (o *ResizeOperation) Name() string { return "resize" }
+
+// Apply resizes the frame image
+func (o *ResizeOperation) Apply(f *Frame) error {
+	img, err := resize(f.Image, &o.Params)
+	if err != nil {
+		return err
+	}
+	f.Image = img
+	return nil
+}
+
+// GrayscaleOperation is an Operation for desaturating the image
+type GrayscaleOperation struct{}
+
+func newGrayscaleOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check(); err != nil {
+		return nil, err
+	}
+	return &GrayscaleOperation{}, nil
+}
+
+// Name returns the operation name
+func (o *GrayscaleOperation) Name() string { return "grayscale" }
+
+// Apply desaturates the frame image
+func (o *GrayscaleOperation) Apply(f *Frame) error {
+	f.Image = imaging.Grayscale(f.Image)
+	return nil
+}
+
+// FlipOperation is an Operation for mirroring the image horizontally (left to right) or vertically (top to bottom)
+type FlipOperation struct {
+	Vertical bool
+}
+
+func newFlipOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("direction"); err != nil {
+		return nil, err
+	}
+
+	switch d := strings.ToLower(args.String("direction", "horizontal")); d {
+	case "horizontal", "h":
+		return &FlipOperation{}, nil
+	case "vertical", "v":
+		return &FlipOperation{Vertical: true}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized direction '%s'; must be one of horizontal, vertical", d)
+	}
+}
+
+// Name returns the operation name
+func (o *FlipOperation) Name() string { return "flip" }
+
+// Apply mirrors the frame image
+func (o *FlipOperation) Apply(f *Frame) error {
+	if o.Vertical {
+		f.Image = imaging.FlipV(f.Image)
+	} else {
+		f.Image = imaging.FlipH(f.Image)
+	}
+	return nil
+}
+
+// RotateOperation is an Operation for rotating the image counter-clockwise by an angle in degrees.
+// The image is enlarged to fit the rotated image, filling the uncovered areas with the background color.
+type RotateOperation struct {
+	Angle      float64
+	Background color.Color
+}
+
+func newRotateOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("angle", "background"); err != nil {
+		return nil, err
+	}
+
+	var err error
+	o := &RotateOperation{Background: color.Transparent}
+	if o.Angle, err = args.Float("angle", 0); err != nil {
+		return nil, err
+	}
+	if c, ok := args["background"]; ok {
+		if o.Background, err = ParseColor(c); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Name returns the operation name
+func (o *RotateOperation) Name() string { return "rotate" }
+
+// Apply rotates the frame image
+func (o *RotateOperation) Apply(f *Frame) error {
+	f.Image = imaging.Rotate(f.Image, o.Angle, o.Background)
+	return nil
+}
+
+// BlurOperation is an Operation for applying a Gaussian blur with the specified sigma to the image
+type BlurOperation struct {
+	Sigma float64
+}
+
+func newBlurOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("sigma"); err != nil {
+		return nil, err
+	}
+
+	sigma, err := args.Float("sigma", 1)
+	if err != nil {
+		return nil, err
+	}
+	if sigma <= 0 {
+		return nil, fmt.Errorf("argument 'sigma' must be positive; got %g", sigma)
+	}
+	return &BlurOperation{Sigma: sigma}, nil
+}
+
+// Name returns the operation name
+func (o *BlurOperation) Name() string { return "blur" }
+
+// Apply blurs the frame image
+func (o *BlurOperation) Apply(f *Frame) error {
+	f.Image = imaging.Blur(f.Image, o.Sigma)
+	return nil
+}
+
+// SharpenOperation is an Operation for sharpening the image with the specified sigma
+type SharpenOperation struct {
+	Sigma float64
+}
+
+func newSharpenOperation(args OperationArgs) (Operation, error) {
+	if err := args.Check("sigma"); err != nil {
+		return nil, err
+	}
+
+	sigma, err := args.Float("sigma", 1)
+	if err != nil {
+		return nil, err
+	}
+	if sigma <= 0 {
+		return nil, fmt.Errorf("argument 'sigma' must be positive; got %g", sigma)
+	}
+	return &SharpenOperation{Sigma: sigma}, nil
+}
+
+// Name returns the operation name
+func (o *SharpenOperation) Name() string { return "sharpen" }
+
+// Apply sharpens the frame image
+func (o *SharpenOperation) Apply(f *Frame) error {
+	f.Image = imaging.Sharpen(f.Image, o.Sigma)
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * File: operation_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:40:27 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 7:05:55 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestParseOperations(t *testing.T) {
+	tests := map[string]struct {
+		spec  string
+		names []string
+		err   bool
+	}{
+		"Single":             {"grayscale", []string{"grayscale"}, false},
+		"Pipeline":           {"resize:width=224,height=224,mode=pad; grayscale ;format:type=png", []string{"resize", "grayscale", "format"}, false},
+		"Primary Argument":   {"format:jpg;rotate:90;flip:vertical;blur:1.5", []string{"format", "rotate", "flip", "blur"}, false},
+		"Trailing Separator": {"sharpen;", []string{"sharpen"}, false},
+		"Empty":              {" ; ", nil, true},
+		"Unknown Operation":  {"posterize", nil, true},
+		"Unknown Argument":   {"resize:width=10,depth=3", nil, true},
+		"Invalid Argument":   {"resize:width=ten", nil, true},
+		"Invalid Resize":     {"resize:width=10,mode=fill", nil, true},
+		"No Primary":         {"grayscale:50", nil, true},
+		"Duplicate":          {"blur:sigma=1,sigma=2", nil, true},
+		"Unencodable":        {"format:webp", nil, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		ops, err := ParseOperations(test.spec)
+		if test.err {
+			assert.Error(t, err)
+			continue
+		}
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		var names []string
+		for _, op := range ops {
+			names = append(names, op.Name())
+		}
+		assert.Equal(t, test.names, names)
+	}
+}
+
+func TestLoadRecipe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emld-recipe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "recipe.yaml")
+	assert.NoError(t, ioutil.WriteFile(valid, []byte(`
+operations:
+  - op: resize
+    width: 224
+    height: 224
+    mode: pad
+    pad-color: "#808080"
+    no-upscale: true
+  - op: format
+    type: png
+`), 0644))
+
+	ops, err := LoadRecipe(valid)
+	assert.NoError(t, err)
+	if assert.Len(t, ops, 2) {
+		resize := ops[0].(*ResizeOperation)
+		assert.Equal(t, 224, resize.Params.Width)
+		assert.Equal(t, ResizePad, resize.Params.Mode)
+		assert.True(t, resize.Params.NoUpscale)
+		assert.Equal(t, &FormatOperation{ContentType: ContentTypePNG}, ops[1])
+	}
+
+	// Unknown keys and arguments are rejected
+	for _, recipe := range []string{"operation:\n  - op: grayscale\n", "operations:\n  - op: grayscale\n    amount: 2\n", "operations: []\n"} {
+		invalid := filepath.Join(dir, "invalid.yaml")
+		assert.NoError(t, ioutil.WriteFile(invalid, []byte(recipe), 0644))
+
+		_, err = LoadRecipe(invalid)
+		assert.Error(t, err)
+	}
+}
+
+func TestApplyOperations(t *testing.T) {
+	pngImg, _ := test_utils.NewImage("image/png", 400, 200)
+
+	webpImg := test_utils.NewWebPImage()
+
+	tests := map[string]struct {
+		img         []byte
+		spec        string
+		contentType string
+		width       int
+		height      int
+		unchanged   bool
+	}{
+		"Resize And Convert": {pngImg, "resize:width=100,height=100,mode=fit;format:jpeg", ContentTypeJPEG, 100, 50, false},
+		"Rotate":             {pngImg, "rotate:90;grayscale", ContentTypePNG, 200, 400, false},
+		"Same Format":        {pngImg, "format:png", ContentTypePNG, 400, 200, true},
+		"Filters":            {pngImg, "blur:2;sharpen;flip", ContentTypePNG, 400, 200, false},
+		"WebP To PNG":        {webpImg, "format:png", ContentTypePNG, 75, 100, false},
+		"WebP Grayscale":     {webpImg, "grayscale", ContentTypePNG, 75, 100, false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		ops, err := ParseOperations(test.spec)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		processed, contentType, err := ApplyOperations(context.Background(), test.img, ops, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, test.contentType, contentType)
+		assert.Equal(t, test.contentType, DetectContentType(processed))
+		assert.Equal(t, test.unchanged, &processed[0] == &test.img[0])
+
+		stats, err := GetStats(processed)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, test.width, stats.Width)
+		assert.Equal(t, test.height, stats.Height)
+	}
+
+	// Cancelled images are not processed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := ApplyOperations(ctx, pngImg, []Operation{&GrayscaleOperation{}}, nil)
+	assert.Equal(t, context.Canceled, err)
+
+	// Images timing out during the last operation are not encoded
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = ApplyOperations(ctx, pngImg, []Operation{&slowOperation{50 * time.Millisecond}}, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// slowOperation is an Operation for testing which only sleeps
+type slowOperation struct {
+	d time.Duration
+}
+
+func (o *slowOperation) Name() string { return "slow" }
+
+func (o *slowOperation) Apply(f *Frame) error {
+	time.Sleep(o.d)
+	f.Image = imaging.Clone(f.Image)
+	return nil
+}
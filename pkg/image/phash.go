@@ -0,0 +1,206 @@
+// Package image provides image processing utilities
+/*
+ * File: phash.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 5:37:27 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:37:27 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// HashKind is a type for representing a perceptual hashing algorithm
+type HashKind int
+
+const (
+	// AHash is the average hash; fast but sensitive to gamma and color histogram changes
+	AHash HashKind = iota
+	// DHash is the difference hash; fast and robust to brightness and contrast changes
+	DHash
+	// PHash is the DCT based perceptual hash; slowest but most robust to re-encoding and resizing
+	PHash
+)
+
+// DefaultHashThreshold is the default maximum Hamming distance between near-duplicate hashes
+const DefaultHashThreshold = 10
+
+// pHashSize is the side length of the image the pHash DCT is computed on
+const pHashSize = 32
+
+// pHashCos is the precomputed DCT-II cosine table for the pHash
+var pHashCos = func() [pHashSize][pHashSize]float64 {
+	var c [pHashSize][pHashSize]float64
+	for u := 0; u < pHashSize; u++ {
+		for x := 0; x < pHashSize; x++ {
+			c[u][x] = math.Cos(float64((2*x+1)*u) * math.Pi / (2 * pHashSize))
+		}
+	}
+	return c
+}()
+
+// String returns the name of the hashing algorithm
+func (k HashKind) String() string {
+	switch k {
+	case AHash:
+		return "ahash"
+	case DHash:
+		return "dhash"
+	case PHash:
+		return "phash"
+	}
+	return fmt.Sprintf("HashKind(%d)", int(k))
+}
+
+// ParseHashKind is a function for parsing a hashing algorithm name e.g. 'phash'
+func ParseHashKind(s string) (HashKind, error) {
+	switch strings.ToLower(s) {
+	case "ahash":
+		return AHash, nil
+	case "dhash":
+		return DHash, nil
+	case "phash":
+		return PHash, nil
+	}
+	return 0, fmt.Errorf("unrecognized hash algorithm '%s'", s)
+}
+
+// FuzzyHash is a function for computing the perceptual hash of an encoded image.
+// Unlike Hash, visually similar images e.g. resized or re-encoded copies produce hashes
+// within a small Hamming distance of each other.
+func FuzzyHash(imgBytes []byte, kind HashKind) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	return HashImage(img, kind)
+}
+
+// HashImage is a function for computing the perceptual hash of a decoded image
+func HashImage(img image.Image, kind HashKind) (uint64, error) {
+	switch kind {
+	case AHash:
+		return AverageHash(img), nil
+	case DHash:
+		return DifferenceHash(img), nil
+	case PHash:
+		return PerceptualHash(img), nil
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm '%s'", kind)
+}
+
+// AverageHash is a function for computing the 64-bit average hash of an image.
+// Each bit is set if the corresponding pixel of the 8x8 grayscale image is brighter than the mean.
+func AverageHash(img image.Image) uint64 {
+	px := grayscalePixels(img, 8, 8)
+
+	var mean float64
+	for _, p := range px {
+		mean += p
+	}
+	mean /= float64(len(px))
+
+	var hash uint64
+	for i, p := range px {
+		if p > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+// DifferenceHash is a function for computing the 64-bit difference hash of an image.
+// Each bit is set if a pixel of the 9x8 grayscale image is brighter than its right neighbor.
+func DifferenceHash(img image.Image) uint64 {
+	px := grayscalePixels(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if px[y*9+x] > px[y*9+x+1] {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+
+	return hash
+}
+
+// PerceptualHash is a function for computing the 64-bit DCT based perceptual hash of an image.
+// Each bit is set if the corresponding low frequency DCT coefficient of the 32x32 grayscale
+// image is above the median of the coefficients.
+func PerceptualHash(img image.Image) uint64 {
+	px := grayscalePixels(img, pHashSize, pHashSize)
+
+	// Separable 2D DCT-II; rows first then only the 8 lowest frequency columns
+	var rows [pHashSize][pHashSize]float64
+	for y := 0; y < pHashSize; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < pHashSize; x++ {
+				sum += px[y*pHashSize+x] * pHashCos[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+
+	coeffs := make([]float64, 0, 64)
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < pHashSize; y++ {
+				sum += rows[y][u] * pHashCos[v][y]
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	// The DC coefficient is excluded from the median as it only encodes the average brightness
+	sorted := append([]float64{}, coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+// HammingDistance is a function for computing the number of differing bits between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayscalePixels is a helper function for downscaling an image and returning its luminance values in row-major order
+func grayscalePixels(img image.Image, width, height int) []float64 {
+	small := imaging.Resize(img, width, height, imaging.Box)
+
+	px := make([]float64, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := small.PixOffset(x, y)
+			r, g, b := small.Pix[i], small.Pix[i+1], small.Pix[i+2]
+			px = append(px, 0.299*float64(r)+0.587*float64(g)+0.114*float64(b))
+		}
+	}
+
+	return px
+}
@@ -0,0 +1,90 @@
+/*
+ * File: phash_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 5:38:02 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:38:02 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestFuzzyHash(t *testing.T) {
+	original, _ := test_utils.NewPatternImage("image/png", 400, 300, 1)
+	resized, _ := test_utils.NewPatternImage("image/jpeg", 200, 150, 1)
+	different, _ := test_utils.NewPatternImage("image/png", 400, 300, 2)
+
+	for _, kind := range []HashKind{AHash, DHash, PHash} {
+		t.Logf("Running test %s", kind)
+
+		h1, err := FuzzyHash(original, kind)
+		assert.NoError(t, err)
+		h2, err := FuzzyHash(resized, kind)
+		assert.NoError(t, err)
+		h3, err := FuzzyHash(different, kind)
+		assert.NoError(t, err)
+
+		assert.LessOrEqual(t, HammingDistance(h1, h2), DefaultHashThreshold, "resized copy should be a near-duplicate")
+		assert.Greater(t, HammingDistance(h1, h3), DefaultHashThreshold, "different image should not be a near-duplicate")
+	}
+}
+
+func TestHashIndexSearch(t *testing.T) {
+	const (
+		Size   = 5000
+		Radius = 8
+	)
+
+	rnd := rand.New(rand.NewSource(1))
+	idx := NewHashIndex()
+
+	hashes := make([]uint64, Size)
+	for i := range hashes {
+		// Cluster hashes around a handful of centers so range queries have hits
+		hashes[i] = uint64(rnd.Intn(8))*0x0123456789abcdef ^ (1 << uint(rnd.Intn(64))) ^ rnd.Uint64()&rnd.Uint64()&rnd.Uint64()
+		idx.Add(hashes[i], string(rune(i)))
+	}
+	assert.Equal(t, Size, idx.Len())
+
+	// Compare against a brute force linear scan
+	for q := 0; q < 50; q++ {
+		query := hashes[rnd.Intn(Size)] ^ (1 << uint(rnd.Intn(64)))
+
+		expected := 0
+		for _, h := range hashes {
+			if HammingDistance(h, query) <= Radius {
+				expected++
+			}
+		}
+
+		matches := idx.Search(query, Radius)
+		assert.Len(t, matches, expected)
+		for i := 1; i < len(matches); i++ {
+			assert.LessOrEqual(t, matches[i-1].Distance, matches[i].Distance)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher(PHash, 4)
+
+	_, ok := m.MatchOrAdd("a", 0xff00ff00ff00ff00)
+	assert.False(t, ok)
+
+	match, ok := m.MatchOrAdd("b", 0xff00ff00ff00ff03)
+	assert.True(t, ok)
+	assert.Equal(t, "a", match.ID)
+	assert.Equal(t, 2, match.Distance)
+
+	_, ok = m.MatchOrAdd("c", 0x00ff00ff00ff00ff)
+	assert.False(t, ok)
+	assert.Equal(t, 2, m.Len())
+}
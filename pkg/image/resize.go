@@ -5,7 +5,7 @@
  * File Created: Saturday, 4th April 2020 10:48:09 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Sunday, 9th May 2021 12:20:23 pm
+ * Last Modified: Saturday, 17th October 2026 6:57:01 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
@@ -14,14 +14,151 @@ import (
 	"bytes"
 	"fmt"
 	"image"
-	"net/http"
+	"image/color"
+	"math"
+	"strings"
 
 	"github.com/disintegration/imaging"
 )
 
+// ResizeMode is how an image is fit to the width and height of SizeConversionParams
+type ResizeMode string
+
+const (
+	// ResizeStretch scales the image to exactly the width and height, distorting its aspect ratio.
+	// If one of width or height is 0, the image aspect ratio is preserved.
+	ResizeStretch ResizeMode = "stretch"
+	// ResizeFit scales the image to fit within the width and height, preserving its aspect ratio
+	ResizeFit ResizeMode = "fit"
+	// ResizeFill scales the image to cover the width and height, preserving its aspect ratio,
+	// and crops the overflow
+	ResizeFill ResizeMode = "fill"
+	// ResizePad scales the image to fit within the width and height, preserving its aspect ratio,
+	// and pads the borders to exactly the width and height (letterboxing)
+	ResizePad ResizeMode = "pad"
+)
+
+// CropSmart is the ResizeFill crop keeping the region with the most detail instead of a fixed anchor
+const CropSmart = "smart"
+
+// resizeModes is the set of supported resize modes
+var resizeModes = map[ResizeMode]bool{
+	ResizeStretch: true,
+	ResizeFit:     true,
+	ResizeFill:    true,
+	ResizePad:     true,
+}
+
+// cropAnchors maps the crop names accepted by SizeConversionParams to their anchor
+var cropAnchors = map[string]imaging.Anchor{
+	"center":       imaging.Center,
+	"top-left":     imaging.TopLeft,
+	"top":          imaging.Top,
+	"top-right":    imaging.TopRight,
+	"left":         imaging.Left,
+	"right":        imaging.Right,
+	"bottom-left":  imaging.BottomLeft,
+	"bottom":       imaging.Bottom,
+	"bottom-right": imaging.BottomRight,
+}
+
+// resampleFilters maps the filter names accepted by SizeConversionParams to their resampling filter
+var resampleFilters = map[string]imaging.ResampleFilter{
+	"nearest":    imaging.NearestNeighbor,
+	"box":        imaging.Box,
+	"linear":     imaging.Linear,
+	"catmullrom": imaging.CatmullRom,
+	"mitchell":   imaging.MitchellNetravali,
+	"lanczos":    imaging.Lanczos,
+}
+
+// Validate is a method for checking the resize params are supported
+func (p *SizeConversionParams) Validate() error {
+	if p.Width < 0 || p.Height < 0 {
+		return fmt.Errorf("invalid resize dimensions %dx%d; must not be negative", p.Width, p.Height)
+	}
+
+	switch p.mode() {
+	case ResizeStretch:
+		if p.Width == 0 && p.Height == 0 {
+			return fmt.Errorf("resize requires a width or height")
+		}
+	case ResizeFit, ResizeFill, ResizePad:
+		if p.Width == 0 || p.Height == 0 {
+			return fmt.Errorf("resize mode '%s' requires both a width and height", p.Mode)
+		}
+	default:
+		return fmt.Errorf("unrecognized resize mode: '%s'; must be one of stretch, fit, fill, pad", p.Mode)
+	}
+
+	if _, err := p.filter(); err != nil {
+		return err
+	}
+
+	if crop := p.crop(); crop != CropSmart {
+		if _, ok := cropAnchors[crop]; !ok {
+			return fmt.Errorf("unrecognized crop: '%s'; must be one of center, top-left, top, top-right, left, right, bottom-left, bottom, bottom-right, smart", p.Crop)
+		}
+	}
+
+	return nil
+}
+
+// mode returns the resize mode, defaulting to ResizeStretch
+func (p *SizeConversionParams) mode() ResizeMode {
+	if p.Mode == "" {
+		return ResizeStretch
+	}
+	return ResizeMode(strings.ToLower(string(p.Mode)))
+}
+
+// crop returns the fill crop, defaulting to center
+func (p *SizeConversionParams) crop() string {
+	if p.Crop == "" {
+		return "center"
+	}
+	return strings.ToLower(p.Crop)
+}
+
+// padColor returns the padding color, defaulting to black
+func (p *SizeConversionParams) padColor() color.Color {
+	if p.PadColor == nil {
+		return color.Black
+	}
+	return p.PadColor
+}
+
+// filter returns the resampling filter, defaulting to Lanczos
+func (p *SizeConversionParams) filter() (imaging.ResampleFilter, error) {
+	if p.Filter == "" {
+		return imaging.Lanczos, nil
+	}
+	if f, ok := resampleFilters[strings.ToLower(p.Filter)]; ok {
+		return f, nil
+	}
+	return imaging.ResampleFilter{}, fmt.Errorf("unrecognized resampling filter: '%s'; must be one of nearest, box, linear, catmullrom, mitchell, lanczos", p.Filter)
+}
+
 // ResizeImage is a function for resizing an image.
 // If one of width or height is 0, the image aspect ratio is preserved.
+// Images are encoded in their original format, or PNG if it can only be decoded e.g. WebP.
 func ResizeImage(imgBytes []byte, width, height int) ([]byte, error) {
+	return ResizeImageWithOptions(imgBytes, width, height, nil)
+}
+
+// ResizeImageWithOptions is a function for resizing an image like ResizeImage, encoding it with the
+// specified options (nil for the defaults)
+func ResizeImageWithOptions(imgBytes []byte, width, height int, opts *EncodeOptions) ([]byte, error) {
+	return ResizeImageWithParams(imgBytes, &SizeConversionParams{Width: width, Height: height}, opts)
+}
+
+// ResizeImageWithParams is a function for resizing an image with the specified mode, crop, padding and
+// resampling filter, encoding it with the specified options (nil for the defaults)
+func ResizeImageWithParams(imgBytes []byte, params *SizeConversionParams, opts *EncodeOptions) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Decode image
 	img, _, err := image.Decode(bytes.NewReader(imgBytes))
 	if err != nil {
@@ -29,24 +166,142 @@ func ResizeImage(imgBytes []byte, width, height int) ([]byte, error) {
 	}
 
 	// Resize the image
-	dstImg := imaging.Resize(img, width, height, imaging.Lanczos)
-
-	// Encode back to original format
-	var encodedImg *bytes.Buffer
-
-	contentType := http.DetectContentType(imgBytes)
-	switch contentType {
-	case ContentTypeJPEG:
-		encodedImg, err = encodeImageToJPEG(dstImg)
-	case ContentTypePNG:
-		encodedImg, err = encodeImageToPNG(dstImg)
-	default:
-		return nil, fmt.Errorf("unsupported MIME type '%s'", contentType)
+	dstImg, err := resize(img, params)
+	if err != nil {
+		return nil, err
 	}
 
+	// Encode back to original format, or PNG for formats which can only be decoded
+	return encodeFormat(dstImg, outputContentType(DetectContentType(imgBytes)), opts)
+}
+
+// resize is a helper function for resizing a decoded image with validated params
+func resize(img image.Image, p *SizeConversionParams) (image.Image, error) {
+	filter, err := p.filter()
 	if err != nil {
 		return nil, err
 	}
 
-	return encodedImg.Bytes(), nil
+	srcW, srcH := img.Bounds().Dx(), img.Bounds().Dy()
+	if srcW <= 0 || srcH <= 0 {
+		return nil, fmt.Errorf("unable to resize empty image")
+	}
+
+	switch p.mode() {
+	case ResizeFit:
+		w, h := scaleDims(srcW, srcH, math.Min(float64(p.Width)/float64(srcW), float64(p.Height)/float64(srcH)), p.NoUpscale)
+		return imaging.Resize(img, w, h, filter), nil
+
+	case ResizeFill:
+		w, h := scaleDims(srcW, srcH, math.Max(float64(p.Width)/float64(srcW), float64(p.Height)/float64(srcH)), p.NoUpscale)
+		scaled := imaging.Resize(img, w, h, filter)
+
+		// Images smaller than the box when not upscaling are only cropped along their larger side
+		cropW, cropH := minInt(p.Width, w), minInt(p.Height, h)
+		if p.crop() == CropSmart {
+			return smartCrop(scaled, cropW, cropH), nil
+		}
+		return imaging.CropAnchor(scaled, cropW, cropH, cropAnchors[p.crop()]), nil
+
+	case ResizePad:
+		w, h := scaleDims(srcW, srcH, math.Min(float64(p.Width)/float64(srcW), float64(p.Height)/float64(srcH)), p.NoUpscale)
+		canvas := imaging.New(p.Width, p.Height, p.padColor())
+		return imaging.PasteCenter(canvas, imaging.Resize(img, w, h, filter)), nil
+	}
+
+	// Stretch, preserving the aspect ratio if one of width or height is 0
+	w, h := p.Width, p.Height
+	if w == 0 {
+		w = int(math.Max(1, math.Floor(float64(h)*float64(srcW)/float64(srcH)+0.5)))
+	}
+	if h == 0 {
+		h = int(math.Max(1, math.Floor(float64(w)*float64(srcH)/float64(srcW)+0.5)))
+	}
+	if p.NoUpscale {
+		w, h = minInt(w, srcW), minInt(h, srcH)
+	}
+	return imaging.Resize(img, w, h, filter), nil
+}
+
+// scaleDims is a helper function for scaling image dimensions by a factor, capped at 1 if not upscaling
+func scaleDims(w, h int, scale float64, noUpscale bool) (int, int) {
+	if noUpscale && scale > 1 {
+		scale = 1
+	}
+	return int(math.Max(1, math.Floor(float64(w)*scale+0.5))), int(math.Max(1, math.Floor(float64(h)*scale+0.5)))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// smartCrop is a helper function for cropping an image to the region of the specified size with
+// the most edge energy i.e. detail, sliding the crop window along each axis independently
+func smartCrop(img *image.NRGBA, width, height int) *image.NRGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if width >= w && height >= h {
+		return img
+	}
+
+	// Luminance of each pixel
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*img.Stride + x*4
+			lum[y*w+x] = 0.299*float64(img.Pix[i]) + 0.587*float64(img.Pix[i+1]) + 0.114*float64(img.Pix[i+2])
+		}
+	}
+
+	// Sum the gradient magnitude of each column and row
+	cols, rows := make([]float64, w), make([]float64, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var e float64
+			if x+1 < w {
+				e += math.Abs(lum[y*w+x+1] - lum[y*w+x])
+			}
+			if y+1 < h {
+				e += math.Abs(lum[(y+1)*w+x] - lum[y*w+x])
+			}
+			cols[x] += e
+			rows[y] += e
+		}
+	}
+
+	x0, y0 := maxWindow(cols, width), maxWindow(rows, height)
+	return imaging.Crop(img, image.Rect(x0, y0, x0+width, y0+height))
+}
+
+// maxWindow is a helper function for finding the offset of the window of the specified size with the
+// largest sum, preferring the most central window on ties
+func maxWindow(values []float64, size int) int {
+	if size >= len(values) {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range values[:size] {
+		sum += v
+	}
+
+	center := (len(values) - size) / 2
+	best, bestSum := 0, sum
+	for i := 1; i+size <= len(values); i++ {
+		sum += values[i+size-1] - values[i-1]
+		if sum > bestSum || (sum == bestSum && absInt(i-center) < absInt(best-center)) {
+			best, bestSum = i, sum
+		}
+	}
+
+	return best
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
 }
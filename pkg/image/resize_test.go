@@ -4,12 +4,17 @@
  * File Created: Tuesday, 14th April 2020 7:55:47 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Wednesday, 5th January 2022 12:06:07 pm
+ * Last Modified: Saturday, 17th October 2026 6:57:01 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,3 +58,159 @@ func TestResize(t *testing.T) {
 		assert.Equal(t, stats.Width, test.convWidth)
 	}
 }
+
+func TestResizeWebP(t *testing.T) {
+	webpImg := test_utils.NewWebPImage()
+
+	newImg, err := ResizeImage(webpImg, 30, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// WebP images can only be decoded so are re-encoded as PNG
+	assert.Equal(t, ContentTypePNG, DetectContentType(newImg))
+	stats, err := GetStats(newImg)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 30, stats.Width)
+		assert.Equal(t, 40, stats.Height)
+	}
+}
+
+func TestResizeModes(t *testing.T) {
+	pngImg, _ := test_utils.NewImage("image/png", 400, 200)
+
+	tests := map[string]struct {
+		params *SizeConversionParams
+		width  int
+		height int
+	}{
+		"Stretch":                 {&SizeConversionParams{Width: 100, Height: 100}, 100, 100},
+		"Stretch Keep Aspect":     {&SizeConversionParams{Width: 100}, 100, 50},
+		"Stretch No Upscale":      {&SizeConversionParams{Width: 800, Height: 100, NoUpscale: true}, 400, 100},
+		"Fit":                     {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFit}, 100, 50},
+		"Fit Upscale":             {&SizeConversionParams{Width: 800, Height: 800, Mode: ResizeFit}, 800, 400},
+		"Fit No Upscale":          {&SizeConversionParams{Width: 800, Height: 800, Mode: ResizeFit, NoUpscale: true}, 400, 200},
+		"Fill":                    {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill}, 100, 100},
+		"Fill Anchor":             {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill, Crop: "top-left"}, 100, 100},
+		"Fill Smart":              {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill, Crop: CropSmart}, 100, 100},
+		"Fill No Upscale":         {&SizeConversionParams{Width: 300, Height: 300, Mode: ResizeFill, NoUpscale: true}, 300, 200},
+		"Pad":                     {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizePad}, 100, 100},
+		"Pad No Upscale":          {&SizeConversionParams{Width: 800, Height: 800, Mode: ResizePad, NoUpscale: true}, 800, 800},
+		"Nearest Filter":          {&SizeConversionParams{Width: 50, Height: 50, Filter: "nearest"}, 50, 50},
+		"Case Insensitive Filter": {&SizeConversionParams{Width: 50, Height: 50, Mode: "FIT", Filter: "CatmullRom"}, 50, 25},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		newImg, err := ResizeImageWithParams(pngImg, test.params, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		stats, err := GetStats(newImg)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, test.width, stats.Width)
+		assert.Equal(t, test.height, stats.Height)
+	}
+}
+
+func TestResizePad(t *testing.T) {
+	// Opaque white 400x200 image
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	png.Encode(&buf, src)
+
+	tests := map[string]struct {
+		params  *SizeConversionParams
+		padding color.Color
+	}{
+		"Default Color": {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizePad}, color.Black},
+		"Custom Color":  {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizePad, PadColor: color.RGBA{0, 0, 255, 255}}, color.RGBA{0, 0, 255, 255}},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		newImg, err := ResizeImageWithParams(buf.Bytes(), test.params, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		img, _, err := image.Decode(bytes.NewReader(newImg))
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		// Letterboxed above and below the 100x50 image
+		assert.Equal(t, color.NRGBAModel.Convert(test.padding), color.NRGBAModel.Convert(img.At(50, 5)))
+		assert.Equal(t, color.NRGBAModel.Convert(test.padding), color.NRGBAModel.Convert(img.At(50, 95)))
+		assert.Equal(t, color.NRGBAModel.Convert(color.White), color.NRGBAModel.Convert(img.At(50, 50)))
+	}
+}
+
+func TestResizeSmartCrop(t *testing.T) {
+	// Flat gray 300x100 image with a checkerboard on the right
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.Gray{128}), image.Point{}, draw.Src)
+	for y := 0; y < 100; y++ {
+		for x := 220; x < 300; x++ {
+			if (x/4+y/4)%2 == 0 {
+				src.Set(x, y, color.Black)
+			} else {
+				src.Set(x, y, color.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, src)
+
+	tests := map[string]struct {
+		crop   string
+		detail bool
+	}{
+		"Center Crop": {"center", false},
+		"Smart Crop":  {CropSmart, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		newImg, err := ResizeImageWithParams(buf.Bytes(), &SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill, Crop: test.crop, Filter: "nearest"}, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		img, _, err := image.Decode(bytes.NewReader(newImg))
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		r, _, _, _ := img.At(50, 50).RGBA()
+		assert.Equal(t, test.detail, r>>8 != 128)
+	}
+}
+
+func TestResizeParamsValidate(t *testing.T) {
+	tests := map[string]struct {
+		params *SizeConversionParams
+		err    bool
+	}{
+		"Stretch":            {&SizeConversionParams{Width: 100}, false},
+		"Fill":               {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill, Crop: "bottom-right"}, false},
+		"No Dimensions":      {&SizeConversionParams{}, true},
+		"Negative Dimension": {&SizeConversionParams{Width: -1, Height: 100}, true},
+		"Fit Missing Height": {&SizeConversionParams{Width: 100, Mode: ResizeFit}, true},
+		"Unknown Mode":       {&SizeConversionParams{Width: 100, Height: 100, Mode: "squash"}, true},
+		"Unknown Crop":       {&SizeConversionParams{Width: 100, Height: 100, Mode: ResizeFill, Crop: "middle"}, true},
+		"Unknown Filter":     {&SizeConversionParams{Width: 100, Filter: "bicubic"}, true},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		err := test.params.Validate()
+		assert.Equal(t, test.err, err != nil, "error=%v", err)
+	}
+}
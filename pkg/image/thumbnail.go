@@ -4,7 +4,7 @@
  * File Created: Sunday, 9th May 2021 12:22:42 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Sunday, 9th May 2021 12:38:55 pm
+ * Last Modified: Saturday, 17th October 2026 6:57:01 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
@@ -12,9 +12,7 @@ package image
 import (
 	"bytes"
 	"encoding/base64"
-	"fmt"
 	"image"
-	"net/http"
 
 	"github.com/disintegration/imaging"
 )
@@ -31,24 +29,13 @@ func Thumbnail(imgBytes []byte, width, height int) (string, int, error) {
 	// Resize the image
 	dstImg := imaging.Thumbnail(img, width, height, imaging.Lanczos)
 
-	// Encode back to original format
-	var encodedImg *bytes.Buffer
-
-	contentType := http.DetectContentType(imgBytes)
-	switch contentType {
-	case ContentTypeJPEG:
-		encodedImg, err = encodeImageToJPEG(dstImg)
-	case ContentTypePNG:
-		encodedImg, err = encodeImageToPNG(dstImg)
-	default:
-		return "", 0, fmt.Errorf("unsupported MIME type '%s'", contentType)
-	}
-
+	// Encode back to original format, or PNG for formats which can only be decoded
+	encodedImg, err := encodeFormat(dstImg, outputContentType(DetectContentType(imgBytes)), nil)
 	if err != nil {
 		return "", 0, err
 	}
 
-	sEnc := base64.StdEncoding.EncodeToString(encodedImg.Bytes())
+	sEnc := base64.StdEncoding.EncodeToString(encodedImg)
 
 	return sEnc, len([]byte(sEnc)), nil
 }
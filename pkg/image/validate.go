@@ -0,0 +1,75 @@
+// Package image provides image processing utilities
+/*
+ * File: validate.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:25:56 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:25:56 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// DefaultMaxPixels is the default maximum number of pixels of a valid image (100 megapixels),
+// guarding against decompression bombs whose small files decode into huge images
+const DefaultMaxPixels = 100 * 1000 * 1000
+
+// ErrUnsupportedFormat is returned when validating an image of a format without a registered decoder
+var ErrUnsupportedFormat = image.ErrFormat
+
+// InvalidImageError is an error for representing an image which failed validation
+type InvalidImageError struct {
+	Reason string
+	Err    error
+}
+
+func (e *InvalidImageError) Error() string {
+	if e.Err == nil {
+		return "invalid image: " + e.Reason
+	}
+	return fmt.Sprintf("invalid image: %s: %s", e.Reason, e.Err.Error())
+}
+
+func (e *InvalidImageError) Unwrap() error {
+	return e.Err
+}
+
+// Validate is a function for checking an image decodes completely, returning its stats.
+// The header is decoded first so zero-pixel images and images of more than maxPixels pixels
+// (0 for no limit) are rejected before the pixels are allocated; the whole image is then decoded
+// to reject truncated and corrupt data. Returns ErrUnsupportedFormat for formats without a registered decoder.
+func Validate(r io.ReadSeeker, maxPixels int64) (*Stats, error) {
+	config, format, err := image.DecodeConfig(r)
+	if err == ErrUnsupportedFormat {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &InvalidImageError{Reason: "corrupt header", Err: err}
+	}
+
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, &InvalidImageError{Reason: fmt.Sprintf("zero pixel dimensions %dx%d", config.Width, config.Height)}
+	}
+	if pixels := int64(config.Width) * int64(config.Height); maxPixels > 0 && pixels > maxPixels {
+		return nil, &InvalidImageError{Reason: fmt.Sprintf("%dx%d exceeds the maximum of %d pixels", config.Width, config.Height, maxPixels)}
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, _, err := image.Decode(r); err != nil {
+		return nil, &InvalidImageError{Reason: "corrupt or truncated data", Err: err}
+	}
+
+	return &Stats{
+		Height:      config.Height,
+		Width:       config.Width,
+		ContentType: format,
+	}, nil
+}
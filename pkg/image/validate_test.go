@@ -0,0 +1,60 @@
+/*
+ * File: validate_test.go
+ * Project: image
+ * File Created: Saturday, 17th October 2026 6:26:47 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:26:47 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package image
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test_utils "gitlab.com/krydus/emeraldai/emerald-tooling/pkg/test"
+)
+
+func TestValidate(t *testing.T) {
+	pngImg, _ := test_utils.NewImage("image/png", 40, 20)
+	jpegImg, _ := test_utils.NewImage("image/jpeg", 40, 20)
+
+	tests := map[string]struct {
+		img       []byte
+		maxPixels int64
+		width     int
+		height    int
+		err       error
+	}{
+		"PNG":             {pngImg, DefaultMaxPixels, 40, 20, nil},
+		"JPEG":            {jpegImg, DefaultMaxPixels, 40, 20, nil},
+		"No Limit":        {pngImg, 0, 40, 20, nil},
+		"Too Many Pixels": {pngImg, 799, 0, 0, &InvalidImageError{}},
+		"Truncated PNG":   {pngImg[:len(pngImg)-20], DefaultMaxPixels, 0, 0, &InvalidImageError{}},
+		"Truncated JPEG":  {jpegImg[:len(jpegImg)/2], DefaultMaxPixels, 0, 0, &InvalidImageError{}},
+		"Corrupt Header":  {pngImg[:20], DefaultMaxPixels, 0, 0, &InvalidImageError{}},
+		"Zero Pixels":     {test_utils.SetPNGDimensions(pngImg, 0, 20), DefaultMaxPixels, 0, 0, &InvalidImageError{}},
+		"Bomb":            {test_utils.SetPNGDimensions(pngImg, 100000, 100000), DefaultMaxPixels, 0, 0, &InvalidImageError{}},
+		"Not An Image":    {[]byte("<html></html>"), DefaultMaxPixels, 0, 0, ErrUnsupportedFormat},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		stats, err := Validate(bytes.NewReader(test.img), test.maxPixels)
+		switch test.err.(type) {
+		case nil:
+			assert.NoError(t, err)
+			assert.Equal(t, test.width, stats.Width)
+			assert.Equal(t, test.height, stats.Height)
+		case *InvalidImageError:
+			var invalid *InvalidImageError
+			assert.True(t, errors.As(err, &invalid), "unexpected error %v", err)
+		default:
+			assert.Equal(t, test.err, err)
+		}
+	}
+}
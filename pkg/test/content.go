@@ -4,18 +4,27 @@
  * File Created: Sunday, 5th April 2020 3:44:51 pm
  * Author: krydus (krydus@proton.me)
  * -----
- * Last Modified: Saturday, 11th April 2020 11:30:11 am
+ * Last Modified: Saturday, 17th October 2026 6:29:00 pm
  * Modified By: krydus (krydus@proton.me>)
  */
 package image
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
+	"image/draw"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"math/rand"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
 )
 
 // NewImage is a function for providing a test image
@@ -26,6 +35,31 @@ func NewImage(mimeType string, width, height int) ([]byte, error) {
 	// Draw a red dot at (2, 3)
 	img.Set(2, 3, color.RGBA{255, 0, 0, 255})
 
+	return encode(img, mimeType)
+}
+
+// NewPatternImage is a function for providing a test image filled with a pattern of random rectangles.
+// Images generated with the same seed are visually identical regardless of their size.
+func NewPatternImage(mimeType string, width, height int, seed int64) ([]byte, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for i := 0; i < 12; i++ {
+		// Rectangles are placed relative to the image size
+		x0, y0 := int(rnd.Float64()*float64(width)), int(rnd.Float64()*float64(height))
+		x1, y1 := x0+int(rnd.Float64()*float64(width)/2), y0+int(rnd.Float64()*float64(height)/2)
+		c := color.RGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 255}
+
+		draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+
+	return encode(img, mimeType)
+}
+
+// encode is a helper function for encoding a test image to the specified MIME type
+func encode(img image.Image, mimeType string) ([]byte, error) {
 	var b = new(bytes.Buffer)
 	var err error
 
@@ -34,6 +68,12 @@ func NewImage(mimeType string, width, height int) ([]byte, error) {
 		err = jpeg.Encode(b, img, nil)
 	case "image/png":
 		err = png.Encode(b, img)
+	case "image/gif":
+		err = gif.Encode(b, img, nil)
+	case "image/bmp":
+		err = bmp.Encode(b, img)
+	case "image/tiff":
+		err = tiff.Encode(b, img, nil)
 	default:
 		return nil, fmt.Errorf("unsupported MIME type '%s'", mimeType)
 	}
@@ -44,3 +84,29 @@ func NewImage(mimeType string, width, height int) ([]byte, error) {
 
 	return b.Bytes(), nil
 }
+
+// SetPNGDimensions is a function for rewriting the dimensions in the header of a PNG without changing its pixel data
+// e.g. to fake a zero-pixel image or a decompression bomb. The header checksum is updated so the header still decodes.
+func SetPNGDimensions(pngBytes []byte, width, height uint32) []byte {
+	// The IHDR chunk follows the 8 byte signature: length (4), type (4), width (4), height (4), ...
+	b := append([]byte{}, pngBytes...)
+	binary.BigEndian.PutUint32(b[16:20], width)
+	binary.BigEndian.PutUint32(b[20:24], height)
+	binary.BigEndian.PutUint32(b[29:33], crc32.ChecksumIEEE(b[12:29]))
+
+	return b
+}
+
+// webpImage is a 75 x 100 lossless WebP image (gopher-doc.1bpp.lossless.webp from golang.org/x/image)
+const webpImage = "UklGRrIBAABXRUJQVlA4TKUBAAAvSsAYAA8w//M///MfeJAkbXvaSG7m8Q3GfYSBJekwQztm/IcZlgwnmWImn2BK7aFmBtnVir6q" +
+	"//8VOkFE/xm4baTIu8c48ArEo6+B3zFKYln3pqClSCKX0begFTAXFOLXHSyF8cCNcZEG4OywuA4KVVfJCiArU7GAgJI8+lJP/OKM" +
+	"T/fBAjevg1cYB7YVkFuWga2lyPi5I0HFy5YTpWIHg0RZpkniRVW9odHAKOwosWuOGdxIyn2OvaCDvhg/we6TwadPBPbqBV58MsLm" +
+	"MJ8yZnOWk8SRz4N+QoyPL+MnamzMvcE1rHNEr91F9GKZPVUcS9w7PhhH36suB9qPeYb/oLk6cuTiJ0wOK3m5h1cKjW6EVZCYMK7d" +
+	"xcKCBdgP9HkKr9gkAO2P8GKZGWVdIAatQa+1IDpt6qyorVwdy01xdW8Jkfk6xjEXmVQQ+HQdFr6OKhIN34dXWq0+0qr6EJSCeeVL" +
+	"H9+gvGTLyqM65PQ44ihzlTXxQKjKbAvshXgir7Lil9w4L2bvMycmjQcqXaMCO6BlY28i+FOLzbfI1vEqxAhotocAAA=="
+
+// NewWebPImage is a function for providing a 75 x 100 test WebP image; WebP images cannot be encoded
+func NewWebPImage() []byte {
+	b, _ := base64.StdEncoding.DecodeString(webpImage)
+	return b
+}
@@ -0,0 +1,121 @@
+// Package throttle provides retry backoff and rate limiting utilities for HTTP clients
+/*
+ * File: backoff.go
+ * Project: throttle
+ * File Created: Saturday, 17th October 2026 5:40:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:40:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package throttle
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy is a struct for representing a retry policy with exponential backoff and full jitter
+type Policy struct {
+	// MaxRetries is the maximum number of retries after the initial attempt
+	MaxRetries int
+	// BaseDelay is the backoff delay of the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay of any retry
+	MaxDelay time.Duration
+}
+
+// jitter is the random source for backoff jitter
+var jitter = struct {
+	*rand.Rand
+	sync.Mutex
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Backoff returns the delay before the specified retry attempt (starting at 0).
+// The delay is drawn uniformly from [0, min(MaxDelay, BaseDelay * 2^attempt)].
+func (p *Policy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	ceil := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || ceil < p.MaxDelay); i++ {
+		ceil *= 2
+	}
+	if p.MaxDelay > 0 && ceil > p.MaxDelay {
+		ceil = p.MaxDelay
+	}
+
+	jitter.Lock()
+	defer jitter.Unlock()
+
+	return time.Duration(jitter.Int63n(int64(ceil) + 1))
+}
+
+// Delay returns the delay before the specified retry attempt, honoring a server supplied
+// Retry-After delay if it is longer than the backoff delay
+func (p *Policy) Delay(attempt int, retryAfter time.Duration) time.Duration {
+	if d := p.Backoff(attempt); d > retryAfter {
+		return d
+	}
+	return retryAfter
+}
+
+// IsRetryableStatus is a function for checking if an HTTP status code indicates a transient failure
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryAfter is a function for parsing the Retry-After header of a response.
+// Both the delay-seconds and HTTP-date forms are supported. Returns 0 if absent or invalid.
+func RetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// Sleep is a function for pausing for the specified duration or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+// Package throttle provides retry backoff and rate limiting utilities for HTTP clients
+/*
+ * File: bucket.go
+ * Project: throttle
+ * File Created: Saturday, 17th October 2026 5:40:21 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:40:21 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket rate limiter.
+// Tokens are added at Rate per second up to a maximum of Burst tokens; each Wait consumes one.
+// A nil Bucket or one with a non-positive Rate does not limit.
+type Bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	sync.Mutex
+}
+
+// NewBucket is a function for initializing a full token bucket
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (b *Bucket) Wait(ctx context.Context) error {
+	if b == nil || b.rate <= 0 {
+		return ctx.Err()
+	}
+
+	// Reserve a token; a negative balance is the debt to wait out
+	b.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.Unlock()
+
+	if err := Sleep(ctx, wait); err != nil {
+		// Return the unused reservation
+		b.Lock()
+		b.tokens++
+		b.Unlock()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+ * File: throttle_test.go
+ * Project: throttle
+ * File Created: Saturday, 17th October 2026 5:41:22 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 5:41:22 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	p := &Policy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		ceil := p.BaseDelay << uint(attempt)
+		if ceil > p.MaxDelay {
+			ceil = p.MaxDelay
+		}
+
+		d := p.Backoff(attempt)
+		assert.GreaterOrEqual(t, int64(d), int64(0))
+		assert.LessOrEqual(t, int64(d), int64(ceil))
+	}
+
+	// Retry-After takes precedence over shorter backoff delays
+	assert.Equal(t, time.Minute, p.Delay(0, time.Minute))
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		"Absent":    {"", 0, 0},
+		"Seconds":   {"120", 2 * time.Minute, 2 * time.Minute},
+		"HTTP Date": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+		"Past Date": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		"Invalid":   {"soon", 0, 0},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		resp := &http.Response{Header: http.Header{}}
+		if test.header != "" {
+			resp.Header.Set("Retry-After", test.header)
+		}
+
+		d := RetryAfter(resp)
+		assert.GreaterOrEqual(t, int64(d), int64(test.min))
+		assert.LessOrEqual(t, int64(d), int64(test.max))
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := NewBucket(100, 2)
+	ctx := context.Background()
+
+	// Burst is immediate, the remainder is paced at the rate
+	start := time.Now()
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, b.Wait(ctx))
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(45*time.Millisecond))
+
+	// Waits are interrupted by the context
+	b = NewBucket(0.001, 1)
+	assert.NoError(t, b.Wait(ctx))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, b.Wait(ctx))
+
+	// Nil buckets do not limit
+	var unlimited *Bucket
+	assert.NoError(t, unlimited.Wait(context.Background()))
+}
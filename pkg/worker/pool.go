@@ -0,0 +1,333 @@
+// Package worker provides a concurrent worker pool for processing streams of items
+/*
+ * File: pool.go
+ * Project: worker
+ * File Created: Saturday, 17th October 2026 6:21:03 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:21:03 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	guuid "github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrClosed is returned when submitting items to a closed or stopped Pool
+var ErrClosed = errors.New("worker pool closed")
+
+// Func is a function for processing a single item.
+// The context is cancelled when the Pool is stopped or the item's timeout elapses.
+type Func func(ctx context.Context, item interface{}) (interface{}, error)
+
+// Options is a struct for representing the configuration of a Pool
+type Options struct {
+	// Workers is the number of items processed concurrently
+	Workers int
+	// QueueSize is the number of submitted items buffered before Submit blocks
+	QueueSize int
+	// OutputSize is the number of results buffered before the workers block
+	OutputSize int
+	// Timeout is the maximum duration of a single item (0 for unlimited).
+	// Items are interrupted through their context, so a Func must observe it to be cut short.
+	Timeout time.Duration
+	// Ordered outputs results in the order their items were submitted
+	Ordered bool
+}
+
+// Result is a struct for representing the outcome of processing an item
+type Result struct {
+	// Index is the submission order of the item, starting at 0
+	Index int64
+	// Item is the submitted item
+	Item interface{}
+	// Value is the value returned by the Func, nil if it panicked
+	Value interface{}
+	// Err is the error returned by the Func, a *TimeoutError or a *PanicError
+	Err error
+}
+
+// Stats is a struct for representing a snapshot of a Pool's counters
+type Stats struct {
+	// Queued is the number of submitted items waiting for a worker
+	Queued int64 `json:"queued"`
+	// InFlight is the number of items being processed
+	InFlight int64 `json:"in_flight"`
+	// Done is the number of items processed, including failures
+	Done int64 `json:"done"`
+	// Failed is the number of items processed with an error
+	Failed int64 `json:"failed"`
+}
+
+// PanicError is an error for representing a panic recovered while processing an item
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic processing item: %v", e.Value)
+}
+
+// TimeoutError is an error for representing an item which failed after exceeding its timeout
+type TimeoutError struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s: %s", e.Timeout, e.Err.Error())
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// job is a struct for representing a submitted item
+type job struct {
+	index int64
+	item  interface{}
+}
+
+// Pool is a struct for processing submitted items with a fixed number of worker routines
+type Pool struct {
+	fn   Func
+	opts Options
+
+	// Counters
+	next                           int64
+	queued, inFlight, done, failed int64
+
+	// Sync vars
+	ctx      context.Context
+	cancel   context.CancelFunc
+	in       chan *job
+	results  chan *Result
+	out      chan *Result
+	window   chan struct{}
+	finished chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewPool is a function for initializing a new Pool processing items with the specified Func.
+// Cancelling the context stops the Pool.
+func NewPool(ctx context.Context, fn Func, opts Options) *Pool {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+	if opts.OutputSize < 0 {
+		opts.OutputSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool{
+		fn:       fn,
+		opts:     opts,
+		ctx:      ctx,
+		cancel:   cancel,
+		in:       make(chan *job, opts.QueueSize),
+		results:  make(chan *Result, opts.Workers),
+		out:      make(chan *Result, opts.OutputSize),
+		finished: make(chan struct{}),
+	}
+
+	// Bound the results held back waiting for an earlier item
+	if opts.Ordered {
+		p.window = make(chan struct{}, opts.QueueSize+opts.Workers+opts.OutputSize)
+	}
+
+	return p
+}
+
+// Start kicks off the Pool worker routines
+func (p *Pool) Start() {
+	p.wg.Add(p.opts.Workers)
+	for i := 0; i < p.opts.Workers; i++ {
+		go func() {
+			p.work()
+			p.wg.Done()
+		}()
+	}
+
+	go func() {
+		p.wg.Wait()
+		close(p.results)
+	}()
+	go p.collect()
+}
+
+// Submit queues an item for processing, blocking while the queue is full.
+// Returns ErrClosed once the Pool is closed or stopped.
+func (p *Pool) Submit(item interface{}) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed || p.ctx.Err() != nil {
+		return ErrClosed
+	}
+
+	if p.window != nil {
+		select {
+		case p.window <- struct{}{}:
+		case <-p.ctx.Done():
+			return ErrClosed
+		}
+	}
+
+	j := &job{index: atomic.AddInt64(&p.next, 1) - 1, item: item}
+	atomic.AddInt64(&p.queued, 1)
+	select {
+	case p.in <- j:
+		return nil
+	case <-p.ctx.Done():
+		atomic.AddInt64(&p.queued, -1)
+		return ErrClosed
+	}
+}
+
+// Results returns the channel of processed items, closed once the Pool is closed and drained or stopped
+func (p *Pool) Results() <-chan *Result {
+	return p.out
+}
+
+// Close stops accepting items and waits for the queued items to be processed and received from Results.
+// Must not be called from the routine receiving the results.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.in)
+	}
+	p.mu.Unlock()
+
+	<-p.finished
+}
+
+// Stop cancels the items being processed, drops the queued items and waits for the worker routines to exit
+func (p *Pool) Stop() {
+	p.cancel()
+	<-p.finished
+}
+
+// Stats returns a snapshot of the Pool's counters
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Queued:   atomic.LoadInt64(&p.queued),
+		InFlight: atomic.LoadInt64(&p.inFlight),
+		Done:     atomic.LoadInt64(&p.done),
+		Failed:   atomic.LoadInt64(&p.failed),
+	}
+}
+
+func (p *Pool) work() {
+	id := guuid.New()
+	log.Debugf("Starting worker routine [%s]\n", id.String())
+	defer log.Debugf("Exiting worker routine [%s]\n", id.String())
+
+	for {
+		select {
+		case j, ok := <-p.in:
+			if !ok || p.ctx.Err() != nil {
+				return
+			}
+
+			atomic.AddInt64(&p.queued, -1)
+			atomic.AddInt64(&p.inFlight, 1)
+			r := p.run(j)
+			atomic.AddInt64(&p.inFlight, -1)
+			atomic.AddInt64(&p.done, 1)
+			if r.Err != nil {
+				atomic.AddInt64(&p.failed, 1)
+			}
+
+			select {
+			case p.results <- r:
+			case <-p.ctx.Done():
+				return
+			}
+
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// run is a helper function for processing an item, recovering a panic as an error
+func (p *Pool) run(j *job) (r *Result) {
+	r = &Result{Index: j.index, Item: j.item}
+
+	ctx := p.ctx
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			log.Errorf("recovered panic processing item %d: %v", j.index, v)
+			r.Value = nil
+			r.Err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	r.Value, r.Err = p.fn(ctx, j.item)
+	if r.Err != nil && ctx.Err() == context.DeadlineExceeded && p.ctx.Err() == nil {
+		r.Err = &TimeoutError{Timeout: p.opts.Timeout, Err: r.Err}
+	}
+
+	return r
+}
+
+// collect is a helper function for outputting results, in submission order if ordered
+func (p *Pool) collect() {
+	defer close(p.finished)
+	defer close(p.out)
+
+	pending := map[int64]*Result{}
+	var next int64
+
+	for r := range p.results {
+		if !p.opts.Ordered {
+			p.emit(r)
+			continue
+		}
+
+		pending[r.Index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			p.emit(r)
+		}
+	}
+}
+
+// emit is a helper function for outputting a result, dropping it once the Pool is stopped
+func (p *Pool) emit(r *Result) {
+	select {
+	case p.out <- r:
+		if p.window != nil {
+			<-p.window
+		}
+	case <-p.ctx.Done():
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * File: pool_test.go
+ * Project: worker
+ * File Created: Saturday, 17th October 2026 6:21:03 pm
+ * Author: krydus (krydus@proton.me)
+ * -----
+ * Last Modified: Saturday, 17th October 2026 6:21:03 pm
+ * Modified By: krydus (krydus@proton.me>)
+ */
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// square is a Func squaring ints after a delay inversely proportional to the item
+func square(ctx context.Context, item interface{}) (interface{}, error) {
+	n := item.(int)
+	if n < 0 {
+		return nil, fmt.Errorf("negative item %d", n)
+	}
+
+	select {
+	case <-time.After(time.Duration(10-n%10) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return n * n, nil
+}
+
+// run is a helper function for submitting items to a Pool and collecting its results
+func run(p *Pool, items []int) []*Result {
+	go func() {
+		for _, n := range items {
+			p.Submit(n)
+		}
+		p.Close()
+	}()
+
+	var results []*Result
+	for r := range p.Results() {
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestPool(t *testing.T) {
+	tests := map[string]struct {
+		opts  Options
+		items []int
+	}{
+		"Unordered":          {Options{Workers: 4, QueueSize: 10, OutputSize: 10}, []int{1, 2, -3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		"Ordered":            {Options{Workers: 4, QueueSize: 10, OutputSize: 10, Ordered: true}, []int{1, 2, -3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		"Ordered Unbuffered": {Options{Workers: 3, Ordered: true}, []int{1, 2, 3, 4, 5, -6, 7, 8, 9}},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test %s", name)
+
+		p := NewPool(context.Background(), square, test.opts)
+		p.Start()
+		results := run(p, test.items)
+
+		assert.Len(t, results, len(test.items))
+		seen := map[int64]bool{}
+		for i, r := range results {
+			if test.opts.Ordered {
+				assert.Equal(t, int64(i), r.Index)
+			}
+			seen[r.Index] = true
+
+			n := test.items[r.Index]
+			assert.Equal(t, n, r.Item)
+			if n < 0 {
+				assert.Error(t, r.Err)
+			} else {
+				assert.NoError(t, r.Err)
+				assert.Equal(t, n*n, r.Value)
+			}
+		}
+		assert.Len(t, seen, len(test.items))
+		assert.Equal(t, Stats{Done: int64(len(test.items)), Failed: 1}, p.Stats())
+
+		assert.Equal(t, ErrClosed, p.Submit(1))
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	fn := func(ctx context.Context, item interface{}) (interface{}, error) {
+		switch item.(string) {
+		case "panic":
+			panic("boom")
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return item, nil
+	}
+
+	p := NewPool(context.Background(), fn, Options{Workers: 2, Timeout: 20 * time.Millisecond, Ordered: true})
+	p.Start()
+
+	go func() {
+		for _, item := range []string{"panic", "slow", "ok"} {
+			p.Submit(item)
+		}
+		p.Close()
+	}()
+
+	results := []*Result{}
+	for r := range p.Results() {
+		results = append(results, r)
+	}
+	assert.Len(t, results, 3)
+
+	var panicErr *PanicError
+	assert.True(t, errors.As(results[0].Err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Nil(t, results[0].Value)
+
+	var timeoutErr *TimeoutError
+	assert.True(t, errors.As(results[1].Err, &timeoutErr))
+	assert.True(t, errors.Is(results[1].Err, context.DeadlineExceeded))
+
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, "ok", results[2].Value)
+	assert.Equal(t, Stats{Done: 3, Failed: 2}, p.Stats())
+}
+
+func TestPoolStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewPool(ctx, square, Options{Workers: 2, QueueSize: 5, OutputSize: 1})
+	p.Start()
+
+	// Fill the queue and output without a consumer
+	for i := 0; i < 8; i++ {
+		assert.NoError(t, p.Submit(i))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		cancel()
+		p.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	assert.Equal(t, ErrClosed, p.Submit(1))
+	for range p.Results() {
+	}
+}
@@ -9,6 +9,8 @@ SEARCH="turkey;bird;birds;turkey flying;turkey dinner;animal;hipo;cat;dog;horse;
 EMLD_CLI_BIN=$PWD/build/bin/emld-cli
 OUTPATH=~/Desktop/emld-demo/images
 LOGPATH=~/Desktop/emld-demo/emld.log
+CACHEPATH=~/Desktop/emld-demo/urls.db
+SERVERS="http://127.0.0.1:8081,http://127.0.0.1:8082,http://127.0.0.1:8083,http://127.0.0.1:8084"
 SIZE=200
 WORKERS=30
 
@@ -25,8 +27,8 @@ touch $LOGPATH
 # Kick off search routines
 for term in "${TERMS[@]}"; do
     echo "Kicking off search process for term=$term pages=$PAGES";
-    ($EMLD_CLI_BIN fetch $term -t images --stream --server "http://127.0.0.1" --pages $PAGES | jq -r '.img_src_b64' | \
-    $EMLD_CLI_BIN download --stream -b -w $WORKERS -p $OUTPATH | \
+    ($EMLD_CLI_BIN fetch $term -t images --stream --server "$SERVERS" --pages $PAGES --cache-path $CACHEPATH | jq -r '.img_src_b64' | \
+    $EMLD_CLI_BIN download --stream -b -w $WORKERS -p $OUTPATH --cache-path $CACHEPATH --allow-type "image/*" --max-bytes 20000000 --validate | \
     $EMLD_CLI_BIN image --stream --replace --silent -f "image/jpeg" -x $SIZE -w $WORKERS) &> $LOGPATH &
 done
 